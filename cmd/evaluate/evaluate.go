@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/embedding"
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/qa"
+)
+
+// GoldenQuestion is one line of the golden question file
+type GoldenQuestion struct {
+	ID       string   `json:"id,omitempty"`
+	Question string   `json:"question"`
+	Expected []string `json:"expected"`           // Rule subsections that answer it (e.g., "13.1c" or "Rule 13.1c")
+	Penalty  string   `json:"penalty,omitempty"`  // Penalty the answer should state (e.g., "one penalty stroke")
+	Keywords []string `json:"keywords,omitempty"` // Words or phrases the answer should contain
+	Format   string   `json:"format,omitempty"`   // Play format the question is about, "match" or "stroke"
+}
+
+// QuestionResult is the evaluation of one golden question
+type QuestionResult struct {
+	ID               string   `json:"id"`
+	Question         string   `json:"question"`
+	Retrieved        []string `json:"retrieved"` // Hierarchy paths of the top k retrieved contexts, best first
+	Recall           float64  `json:"recall"`
+	ReciprocalRank   float64  `json:"reciprocal_rank"`
+	Answer           string   `json:"answer,omitempty"`
+	Citations        []string `json:"citations,omitempty"`
+	CitationAccuracy *float64 `json:"citation_accuracy,omitempty"` // Absent when the answer cites no rules
+	GroundingScore   float64  `json:"grounding_score,omitempty"`
+	KeywordsMatched  int      `json:"keywords_matched"`
+	Keywords         int      `json:"keywords"`
+	PenaltyMatched   *bool    `json:"penalty_matched,omitempty"` // Absent when no penalty is expected
+	Error            string   `json:"error,omitempty"`
+}
+
+// Summary aggregates the results of a run. Answer metrics are averaged over
+// the questions they apply to, and are zero in retrieval-only runs.
+type Summary struct {
+	Questions        int     `json:"questions"`
+	Errors           int     `json:"errors"`
+	K                int     `json:"k"`
+	RecallAtK        float64 `json:"recall_at_k"`
+	MRR              float64 `json:"mrr"`
+	CitationAccuracy float64 `json:"citation_accuracy"`
+	KeywordMatchRate float64 `json:"keyword_match_rate"`
+	PenaltyAccuracy  float64 `json:"penalty_accuracy"`
+}
+
+// Settings records what a run evaluated, so that reports can be compared
+type Settings struct {
+	Golden         string `json:"golden"`
+	Index          string `json:"index"`
+	Provider       string `json:"provider"`
+	Model          string `json:"model,omitempty"`
+	EmbeddingModel string `json:"embedding_model"`
+	Retrieval      string `json:"retrieval"`
+	Rerank         string `json:"rerank,omitempty"`
+	RetrievalOnly  bool   `json:"retrieval_only"`
+}
+
+// Report is the machine-readable result of a run
+type Report struct {
+	Settings  Settings         `json:"settings"`
+	Summary   Summary          `json:"summary"`
+	Questions []QuestionResult `json:"questions"`
+}
+
+// loadGolden reads a JSONL file of golden questions, skipping blank lines
+func loadGolden(path string) ([]GoldenQuestion, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open golden questions: %w", err)
+	}
+	defer file.Close()
+
+	var questions []GoldenQuestion
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var question GoldenQuestion
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&question); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if question.Question == "" {
+			return nil, fmt.Errorf("%s:%d: question is required", path, line)
+		}
+		if len(question.Expected) == 0 {
+			return nil, fmt.Errorf("%s:%d: expected subsections are required", path, line)
+		}
+		if question.Format != "" && question.Format != models.PlayFormatMatch && question.Format != models.PlayFormatStroke {
+			return nil, fmt.Errorf("%s:%d: unknown format %q (expected match or stroke)", path, line, question.Format)
+		}
+		if question.ID == "" {
+			question.ID = fmt.Sprintf("line-%d", line)
+		}
+		for i, expected := range question.Expected {
+			question.Expected[i] = strings.TrimPrefix(strings.TrimSpace(expected), "Rule ")
+		}
+
+		questions = append(questions, question)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read golden questions: %w", err)
+	}
+
+	return questions, nil
+}
+
+// evaluator runs golden questions through the question answering pipeline
+type evaluator struct {
+	store         database.VectorStore
+	embedder      embedding.Embedder
+	llmClient     llm.Generator // Nil in retrieval-only runs
+	opts          qa.Options
+	retrievalOnly bool
+}
+
+// evaluate scores one question. Pipeline failures are recorded on the
+// result rather than stopping the run.
+func (e *evaluator) evaluate(ctx context.Context, question GoldenQuestion) QuestionResult {
+	result := QuestionResult{
+		ID:        question.ID,
+		Question:  question.Question,
+		Retrieved: []string{},
+		Keywords:  len(question.Keywords),
+	}
+
+	opts := e.opts
+	opts.PlayFormat = question.Format
+
+	var sources []models.TextChunk
+	var response *models.Response
+	var err error
+	if e.retrievalOnly {
+		sources, err = qa.Retrieve(ctx, question.Question, e.store, e.embedder, opts)
+	} else {
+		response, err = qa.Answer(ctx, question.Question, e.store, e.embedder, e.llmClient, opts, nil)
+		if response != nil {
+			sources = response.Sources
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	scoreRetrieval(&result, question, sources, opts.ContextLimit)
+	if response != nil {
+		scoreAnswer(&result, question, response)
+	}
+
+	return result
+}
+
+// scoreRetrieval computes the share of expected subsections the top k
+// sources cover, and the reciprocal rank of the first of them covering any.
+// Chunks added after the ranked contexts, by following cross-references or
+// as the interpretations and Model Local Rules of a context, are not scored.
+func scoreRetrieval(result *QuestionResult, question GoldenQuestion, sources []models.TextChunk, k int) {
+	var keys []string
+	for i := range sources {
+		if sources[i].ReferenceScore != 0 || sources[i].Clarifies != "" {
+			continue
+		}
+		if len(keys) == k {
+			break
+		}
+		result.Retrieved = append(result.Retrieved, sources[i].Metadata.Hierarchy)
+		keys = append(keys, citation.RuleKey(&sources[i]))
+	}
+
+	found := 0
+	for _, expected := range question.Expected {
+		if citation.CoveredBy(expected, keys) {
+			found++
+		}
+	}
+	result.Recall = float64(found) / float64(len(question.Expected))
+
+	for rank, key := range keys {
+		if key == "" {
+			continue
+		}
+		if coversAny(key, question.Expected) {
+			result.ReciprocalRank = 1 / float64(rank+1)
+			break
+		}
+	}
+}
+
+// scoreAnswer checks the answer's citations, penalty and keywords
+func scoreAnswer(result *QuestionResult, question GoldenQuestion, response *models.Response) {
+	result.Answer = response.Answer
+	result.GroundingScore = response.GroundingScore
+
+	answer := strings.ToLower(response.Answer)
+
+	cited := citation.Extract(response.Answer)
+	if len(cited) > 0 {
+		correct := 0
+		for _, reference := range cited {
+			if citation.CoveredBy(strings.TrimPrefix(reference, "Rule "), question.Expected) {
+				correct++
+			}
+		}
+		accuracy := float64(correct) / float64(len(cited))
+		result.Citations = cited
+		result.CitationAccuracy = &accuracy
+	}
+
+	for _, keyword := range question.Keywords {
+		if strings.Contains(answer, strings.ToLower(keyword)) {
+			result.KeywordsMatched++
+		}
+	}
+
+	if question.Penalty != "" {
+		matched := strings.Contains(answer, strings.ToLower(question.Penalty))
+		result.PenaltyMatched = &matched
+	}
+}
+
+// coversAny reports whether a source key covers any of the expected subsections
+func coversAny(key string, expected []string) bool {
+	for _, number := range expected {
+		if citation.CoveredBy(number, []string{key}) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize averages the results of a run
+func summarize(results []QuestionResult, k int) Summary {
+	summary := Summary{Questions: len(results), K: k}
+
+	var scored, cited, keywords, keywordsMatched, penalties, penaltiesMatched int
+	var citationAccuracy float64
+	for _, result := range results {
+		if result.Error != "" {
+			summary.Errors++
+			continue
+		}
+		scored++
+		summary.RecallAtK += result.Recall
+		summary.MRR += result.ReciprocalRank
+
+		if result.CitationAccuracy != nil {
+			cited++
+			citationAccuracy += *result.CitationAccuracy
+		}
+		if result.Answer != "" {
+			keywords += result.Keywords
+			keywordsMatched += result.KeywordsMatched
+		}
+		if result.PenaltyMatched != nil {
+			penalties++
+			if *result.PenaltyMatched {
+				penaltiesMatched++
+			}
+		}
+	}
+
+	if scored > 0 {
+		summary.RecallAtK /= float64(scored)
+		summary.MRR /= float64(scored)
+	}
+	if cited > 0 {
+		summary.CitationAccuracy = citationAccuracy / float64(cited)
+	}
+	if keywords > 0 {
+		summary.KeywordMatchRate = float64(keywordsMatched) / float64(keywords)
+	}
+	if penalties > 0 {
+		summary.PenaltyAccuracy = float64(penaltiesMatched) / float64(penalties)
+	}
+
+	return summary
+}
+
+// printResult prints one line per question
+func printResult(result QuestionResult) {
+	if result.Error != "" {
+		fmt.Printf("%-18s error: %s\n", result.ID, result.Error)
+		return
+	}
+
+	line := fmt.Sprintf("%-18s recall %.2f  rr %.2f", result.ID, result.Recall, result.ReciprocalRank)
+	if result.Answer != "" {
+		if result.CitationAccuracy != nil {
+			line += fmt.Sprintf("  citations %.2f", *result.CitationAccuracy)
+		} else {
+			line += "  citations -"
+		}
+		line += fmt.Sprintf("  keywords %d/%d", result.KeywordsMatched, result.Keywords)
+		if result.PenaltyMatched != nil {
+			line += fmt.Sprintf("  penalty %v", *result.PenaltyMatched)
+		}
+	}
+	fmt.Println(line)
+}
+
+// printSummary prints the aggregate metrics of a run
+func printSummary(summary Summary, retrievalOnly bool) {
+	fmt.Printf("\n%d questions (%d failed)\n", summary.Questions, summary.Errors)
+	fmt.Printf("  recall@%d           %.3f\n", summary.K, summary.RecallAtK)
+	fmt.Printf("  MRR                %.3f\n", summary.MRR)
+	if retrievalOnly {
+		return
+	}
+	fmt.Printf("  citation accuracy  %.3f\n", summary.CitationAccuracy)
+	fmt.Printf("  keyword match rate %.3f\n", summary.KeywordMatchRate)
+	fmt.Printf("  penalty accuracy   %.3f\n", summary.PenaltyAccuracy)
+}
+
+// writeReport writes the report as indented JSON to path, or to stdout for "-"
+func writeReport(report Report, path string) error {
+	// Keep hierarchy paths such as "Rule 13 > 13.1" readable in diffs
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	if path == "-" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
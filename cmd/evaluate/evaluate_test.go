@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestScoreRetrieval(t *testing.T) {
+	chunk := func(subsection string) models.TextChunk {
+		return models.TextChunk{Metadata: models.Metadata{Subsection: subsection, Hierarchy: subsection}}
+	}
+	referenced := func(subsection string) models.TextChunk {
+		c := chunk(subsection)
+		c.ReferenceScore = 0.5
+		return c
+	}
+	clarifying := func(subsection string) models.TextChunk {
+		c := chunk(subsection)
+		c.Clarifies = subsection
+		c.Metadata.ChunkType = models.ChunkTypeInterpretation
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		expected  []string
+		sources   []models.TextChunk
+		k         int
+		recall    float64
+		rank      float64
+		retrieved []string
+	}{
+		{
+			name:      "first context",
+			expected:  []string{"13.2a"},
+			sources:   []models.TextChunk{chunk("13.2a"), chunk("13.1")},
+			k:         2,
+			recall:    1,
+			rank:      1,
+			retrieved: []string{"13.2a", "13.1"},
+		},
+		{
+			name:      "second context covers part",
+			expected:  []string{"14.3c", "16.1"},
+			sources:   []models.TextChunk{chunk("13.1"), chunk("14.3")},
+			k:         2,
+			recall:    0.5,
+			rank:      0.5,
+			retrieved: []string{"13.1", "14.3"},
+		},
+		{
+			name:      "cross-referenced chunks are not scored",
+			expected:  []string{"16.1"},
+			sources:   []models.TextChunk{chunk("13.1"), chunk("14.3"), referenced("16.1")},
+			k:         2,
+			recall:    0,
+			rank:      0,
+			retrieved: []string{"13.1", "14.3"},
+		},
+		{
+			name:      "interpretations are not scored",
+			expected:  []string{"10.2b", "14.3"},
+			sources:   []models.TextChunk{chunk("13.1"), clarifying("10.2b"), chunk("14.3")},
+			k:         2,
+			recall:    0.5,
+			rank:      0.5,
+			retrieved: []string{"13.1", "14.3"},
+		},
+		{
+			name:      "only the top k are scored",
+			expected:  []string{"16.1"},
+			sources:   []models.TextChunk{chunk("13.1"), chunk("14.3"), chunk("16.1")},
+			k:         2,
+			recall:    0,
+			rank:      0,
+			retrieved: []string{"13.1", "14.3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := QuestionResult{Retrieved: []string{}}
+			scoreRetrieval(&result, GoldenQuestion{Expected: tt.expected}, tt.sources, tt.k)
+
+			if result.Recall != tt.recall || result.ReciprocalRank != tt.rank {
+				t.Errorf("recall %v, reciprocal rank %v, want %v and %v",
+					result.Recall, result.ReciprocalRank, tt.recall, tt.rank)
+			}
+			if !reflect.DeepEqual(result.Retrieved, tt.retrieved) {
+				t.Errorf("retrieved %q, want %q", result.Retrieved, tt.retrieved)
+			}
+		})
+	}
+}
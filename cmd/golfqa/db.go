@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"golf-rules-rag/internal/database"
+)
+
+// runDBCommand handles the `golfqa db migrate|status|rollback` subcommands
+func runDBCommand(args []string) {
+	fs := flag.NewFlagSet("db", flag.ExitOnError)
+	pgConnString := fs.String("pg", defaultPGConnString, "PostgreSQL connection string")
+	steps := fs.Int("steps", 1, "Number of migrations to roll back")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: golfqa db <migrate|status|rollback> [flags]")
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+
+	ctx := context.Background()
+
+	db, err := database.NewDB(*pgConnString)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	switch action {
+	case "migrate":
+		applied, err := db.Migrate(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database schema is up to date")
+		}
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("  %04d_%-30s %s\n", status.Version, status.Name, state)
+		}
+		if err := db.CheckSchema(ctx); err != nil {
+			fmt.Printf("\n%v\n", err)
+		}
+
+	case "rollback":
+		reverted, err := db.Rollback(ctx, *steps)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
+		for _, m := range reverted {
+			fmt.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/diff"
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+)
+
+// runDiffMode prints what changed between two editions, given as "old:new".
+// Each side names an index or the edition recorded for one. The comparison is
+// limited to ruleFilter when set. When llmClient is not nil it is asked to
+// summarise the practical impact of every change.
+func runDiffMode(ctx context.Context, store database.VectorStore, spec, ruleFilter string, llmClient llm.Generator) error {
+	oldName, newName, ok := strings.Cut(spec, ":")
+	if !ok || oldName == "" || newName == "" {
+		return fmt.Errorf("invalid -diff %q (expected old:new, e.g. 2019:2023)", spec)
+	}
+
+	indexes, err := store.ListIndexes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	oldIndex, err := resolveEdition(indexes, oldName)
+	if err != nil {
+		return err
+	}
+	newIndex, err := resolveEdition(indexes, newName)
+	if err != nil {
+		return err
+	}
+
+	if ruleFilter != "" && !strings.HasPrefix(ruleFilter, "Rule ") {
+		ruleFilter = "Rule " + ruleFilter
+	}
+
+	oldChunks, err := editionChunks(ctx, store.WithIndex(oldIndex.Name), ruleFilter)
+	if err != nil {
+		return fmt.Errorf("failed to read index %q: %w", oldIndex.Name, err)
+	}
+	newChunks, err := editionChunks(ctx, store.WithIndex(newIndex.Name), ruleFilter)
+	if err != nil {
+		return fmt.Errorf("failed to read index %q: %w", newIndex.Name, err)
+	}
+
+	changes := diff.Editions(oldChunks, newChunks)
+
+	scope := "the rules"
+	if ruleFilter != "" {
+		scope = ruleFilter
+	}
+	fmt.Printf("Changes to %s between %s and %s:\n", scope, editionLabel(oldIndex), editionLabel(newIndex))
+	if len(changes) == 0 {
+		fmt.Println("  No changes found")
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	fmt.Printf("  %d added, %d removed, %d modified\n", counts[diff.Added], counts[diff.Removed], counts[diff.Modified])
+
+	for _, change := range changes {
+		fmt.Printf("\n%s %s", changeMarker(change.Kind), change.Key)
+		if change.Title != "" {
+			fmt.Printf(" – %s", change.Title)
+		}
+		fmt.Printf(" (%s)\n", change.Kind)
+
+		switch change.Kind {
+		case diff.Added:
+			fmt.Println(indent(change.New))
+		case diff.Removed:
+			fmt.Println(indent(change.Old))
+		default:
+			fmt.Println(indent(change.Diff))
+		}
+
+		if llmClient != nil {
+			prompt := llm.GenerateChangePrompt(change.Key, editionLabel(oldIndex), editionLabel(newIndex),
+				change.Old, change.New)
+			summary, err := llmClient.GenerateResponse(ctx, prompt)
+			if err != nil {
+				return fmt.Errorf("failed to summarise change to %s: %w", change.Key, err)
+			}
+			fmt.Println(indent("Impact: " + strings.TrimSpace(summary)))
+		}
+	}
+
+	return nil
+}
+
+// resolveEdition finds the index named name, or else the single index
+// recorded with that edition
+func resolveEdition(indexes []models.IndexInfo, name string) (models.IndexInfo, error) {
+	var matches []models.IndexInfo
+	for _, index := range indexes {
+		if index.Name == name {
+			return index, nil
+		}
+		if index.Edition == name {
+			matches = append(matches, index)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return models.IndexInfo{}, fmt.Errorf("no index or edition named %q; run with -list-indexes to see the available indexes", name)
+	case 1:
+		return matches[0], nil
+	default:
+		var names []string
+		for _, index := range matches {
+			names = append(names, index.Name)
+		}
+		return models.IndexInfo{}, fmt.Errorf("edition %q is held by several indexes (%s); name one of them instead",
+			name, strings.Join(names, ", "))
+	}
+}
+
+// editionChunks returns the chunks of one rule, or of every rule when ruleFilter is empty
+func editionChunks(ctx context.Context, store database.VectorStore, ruleFilter string) ([]models.TextChunk, error) {
+	if ruleFilter != "" {
+		return store.QueryByRuleNumber(ctx, ruleFilter)
+	}
+
+	sections, err := store.GetRuleSections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []models.TextChunk
+	for _, section := range sections {
+		sectionChunks, err := store.QueryByRuleNumber(ctx, section)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, sectionChunks...)
+	}
+	return chunks, nil
+}
+
+// editionLabel names an index by its edition when one is recorded
+func editionLabel(index models.IndexInfo) string {
+	if index.Edition == "" || index.Edition == index.Name {
+		return index.Name
+	}
+	return fmt.Sprintf("%s (%s)", index.Edition, index.Name)
+}
+
+// changeMarker returns the diff-style marker for a kind of change
+func changeMarker(kind string) string {
+	switch kind {
+	case diff.Added:
+		return "+"
+	case diff.Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// indent prefixes every line of text with four spaces
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n    ")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// TestIndexAndAnswer builds the indexer and golfqa, indexes a small rulebook
+// into the file store and answers a question from it, all with the fake
+// providers
+func TestIndexAndAnswer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the binaries")
+	}
+
+	dir := t.TempDir()
+	indexer := filepath.Join(dir, "indexer")
+	golfqa := filepath.Join(dir, "golfqa")
+	for binary, pkg := range map[string]string{indexer: "../indexer", golfqa: "."} {
+		if output, err := exec.Command("go", "build", "-o", binary, pkg).CombinedOutput(); err != nil {
+			t.Fatalf("failed to build %s: %v\n%s", pkg, err, output)
+		}
+	}
+
+	pdfPath := filepath.Join(dir, "rules.pdf")
+	if err := os.WriteFile(pdfPath, buildPDF([][]string{
+		{
+			"Rule 13 - Putting Greens",
+			"13.1 Actions Allowed or Required on Putting Greens",
+			"13.1a When Ball Is on Putting Green",
+			"A ball is on the putting green when any part of it touches the putting green.",
+		},
+		{
+			"13.2 The Flagstick",
+			"13.2a Leaving Flagstick in Hole",
+			"The player may leave the flagstick in the hole. See Rule 13.1a.",
+			"Rule 14 - Procedures for Ball",
+			"14.1 Marking, Lifting and Cleaning Ball",
+			"A ball to be lifted must be marked before it is lifted.",
+		},
+	}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	storePath := filepath.Join(dir, "rules.index")
+	run := func(binary string, args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(binary, append([]string{"-store", "file", "-store-path", storePath, "-provider", "fake"}, args...)...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("%s %s failed: %v\n%s", filepath.Base(binary), strings.Join(args, " "), err, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	run(indexer, "-pdf", pdfPath)
+	output := run(golfqa, "-stream=false", "-q", "Can I leave the flagstick in the hole?")
+
+	for _, want := range []string{
+		"Fake answer to: Can I leave the flagstick in the hole?",
+		"Rule 13 > 13.2 > 13.2a",
+		"Sources:\n  1. [Section: Rule 13 - Putting Greens, Page: 2]",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("golfqa output does not contain %q:\n%s", want, output)
+		}
+	}
+
+	output = run(golfqa, "-show", "13.2a")
+	if !strings.Contains(output, "The player may leave the flagstick in the hole.") {
+		t.Errorf("golfqa -show 13.2a output does not contain the rule text:\n%s", output)
+	}
+}
+
+// buildPDF writes a minimal PDF with one page per element of pages, each
+// line set in Helvetica. Headings, the lines starting with "Rule " or a
+// number, are set larger than body text.
+func buildPDF(pages [][]string) []byte {
+	// Objects 1 to 3 are the catalog, the page tree and the font; each page
+	// and its content stream follow
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	for i, lines := range pages {
+		var content strings.Builder
+		y := 750
+		for _, line := range lines {
+			size := 10
+			if strings.HasPrefix(line, "Rule ") || unicode.IsDigit(rune(line[0])) {
+				size = 14
+			}
+			fmt.Fprintf(&content, "BT /F1 %d Tf 72 %d Td (%s) Tj ET\n", size, y, escape.Replace(line))
+			y -= 20
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "+
+				"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return pdf.Bytes()
+}
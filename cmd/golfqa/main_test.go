@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestFormatSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		response models.Response
+		want     string
+	}{
+		{
+			name: "no sources",
+			want: "",
+		},
+		{
+			name: "sources",
+			response: models.Response{Sources: []models.TextChunk{
+				{Metadata: models.Metadata{Section: "Rule 13", Title: "Putting Greens", PageNumber: 120}},
+				{Metadata: models.Metadata{Section: "Rule 14", Title: "Procedures for Ball", PageNumber: 130, EndPage: 131}},
+			}},
+			want: "Sources:\n" +
+				"  1. [Section: Rule 13 - Putting Greens, Page: 120]\n" +
+				"  2. [Section: Rule 14 - Procedures for Ball, Page: 130-131]\n",
+		},
+		{
+			name: "missing section and title",
+			response: models.Response{Sources: []models.TextChunk{
+				{Metadata: models.Metadata{PageNumber: 3}},
+			}},
+			want: "Sources:\n  1. [Section: N/A - N/A, Page: 3]\n",
+		},
+		{
+			name: "reranked and cross-referenced",
+			response: models.Response{Sources: []models.TextChunk{
+				{Metadata: models.Metadata{Section: "Rule 13", Title: "Putting Greens", PageNumber: 120}, RerankScore: 8.5},
+				{Metadata: models.Metadata{Section: "Rule 14", Title: "Procedures for Ball", PageNumber: 130}, ReferenceScore: 0.5},
+			}},
+			want: "Sources:\n" +
+				"  1. [Section: Rule 13 - Putting Greens, Page: 120] (relevance 8.50)\n" +
+				"  2. [Section: Rule 14 - Procedures for Ball, Page: 130] (cross-referenced)\n",
+		},
+		{
+			name: "Official Guide",
+			response: models.Response{Sources: []models.TextChunk{
+				{Metadata: models.Metadata{Section: "Rule 10", Title: "Preparing for and Making a Stroke", PageNumber: 90,
+					Hierarchy: "Rule 10 > 10.2 > 10.2b > Interpretation 10.2b/1", ChunkType: models.ChunkTypeInterpretation}},
+				{Metadata: models.Metadata{Section: "Rule 14", PageNumber: 120,
+					Hierarchy: "Interpretation 14/1", ChunkType: models.ChunkTypeInterpretation}},
+				{Metadata: models.Metadata{Section: "Rule 1", PageNumber: 10,
+					Hierarchy: "1", ChunkType: models.ChunkTypeInterpretation}},
+				{Metadata: models.Metadata{Section: "Rule 2", PageNumber: 20,
+					ChunkType: models.ChunkTypeInterpretation}},
+				{Metadata: models.Metadata{Section: "Model Local Rule E-5", Title: "Alternative to Stroke and Distance",
+					PageNumber: 450, ChunkType: models.ChunkTypeModelLocalRule}},
+			}},
+			want: "Sources:\n" +
+				"  1. [Section: Rule 10 - Preparing for and Making a Stroke, Page: 90] (Interpretation 10.2b/1)\n" +
+				"  2. [Section: Rule 14 - N/A, Page: 120] (Interpretation 14/1)\n" +
+				"  3. [Section: Rule 1 - N/A, Page: 10] (1)\n" +
+				"  4. [Section: Rule 2 - N/A, Page: 20] (Interpretation)\n" +
+				"  5. [Section: Model Local Rule E-5 - Alternative to Stroke and Distance, Page: 450] (Committee Procedures)\n",
+		},
+		{
+			name: "definitions",
+			response: models.Response{
+				Sources: []models.TextChunk{
+					{Metadata: models.Metadata{Section: "Rule 15", Title: "Relief from Loose Impediments", PageNumber: 140}},
+				},
+				Definitions: []models.TextChunk{
+					{Metadata: models.Metadata{Title: "Loose Impediment"}},
+					{Metadata: models.Metadata{Title: "Bunker"}},
+				},
+			},
+			want: "Sources:\n" +
+				"  1. [Section: Rule 15 - Relief from Loose Impediments, Page: 140]\n" +
+				"Definitions: Loose Impediment, Bunker\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSources(&tt.response); got != tt.want {
+				t.Errorf("formatSources() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		response models.Response
+		want     string
+	}{
+		{
+			name:     "without sources",
+			response: models.Response{Answer: "No relevant rule."},
+			want:     "No relevant rule.\n\n",
+		},
+		{
+			name: "with sources",
+			response: models.Response{
+				Answer: "You may leave the flagstick in the hole (Rule 13.2a).",
+				Sources: []models.TextChunk{
+					{Metadata: models.Metadata{Section: "Rule 13", Title: "Putting Greens", PageNumber: 120}},
+				},
+			},
+			want: "You may leave the flagstick in the hole (Rule 13.2a).\n\n" +
+				"Sources:\n  1. [Section: Rule 13 - Putting Greens, Page: 120]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatAnswer(&tt.response); got != tt.want {
+				t.Errorf("formatAnswer() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+// runPenaltyMode prints the penalties stated for a rule, given as "14.3" or
+// "Rule 14.3". Penalties for parts of the rule and for the parts containing
+// it are included, since both apply to a breach of it.
+func runPenaltyMode(ctx context.Context, store database.VectorStore, rule string) error {
+	number := strings.TrimPrefix(strings.TrimSpace(rule), "Rule ")
+	if number == "" {
+		return fmt.Errorf("invalid -penalty %q (expected a rule, e.g. 'Rule 14.3')", rule)
+	}
+	rule = "Rule " + number
+
+	mainRule, _, _ := strings.Cut(number, ".")
+	chunks, err := store.QueryByRuleNumber(ctx, "Rule "+mainRule)
+	if err != nil {
+		return fmt.Errorf("failed to read Rule %s: %w", mainRule, err)
+	}
+	if len(chunks) == 0 {
+		return fmt.Errorf("Rule %s not found; run with -list-rules to see the available rules", mainRule)
+	}
+
+	type found struct {
+		penalty models.Penalty
+		source  models.TextChunk
+	}
+	var penalties []found
+	seen := make(map[models.Penalty]bool)
+	for _, chunk := range chunks {
+		for _, penalty := range chunk.Penalties {
+			penaltyNumber := strings.TrimPrefix(penalty.Rule, "Rule ")
+			if !citation.CoveredBy(number, []string{penaltyNumber}) && !citation.CoveredBy(penaltyNumber, []string{number}) {
+				continue
+			}
+
+			key := models.Penalty{Kind: penalty.Kind, Rule: penalty.Rule}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			penalties = append(penalties, found{penalty: penalty, source: chunk})
+		}
+	}
+
+	if len(penalties) == 0 {
+		fmt.Printf("No penalty statements found for %s\n", rule)
+		return nil
+	}
+
+	fmt.Printf("Penalties for %s:\n", rule)
+	for _, f := range penalties {
+		fmt.Printf("\n  %s\n", f.penalty)
+		fmt.Printf("    %q\n", f.penalty.Text)
+		fmt.Printf("    [Source: %s, Page: %s]\n", f.source.Metadata.Hierarchy, f.source.Metadata.Pages())
+	}
+	return nil
+}
+
+// formatPenalties lists the penalties of a response, or returns "" if there are none
+func formatPenalties(response *models.Response) string {
+	if len(response.Penalties) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Penalties:\n")
+	for _, penalty := range response.Penalties {
+		sb.WriteString("  - " + penalty.String() + "\n")
+	}
+	return sb.String()
+}
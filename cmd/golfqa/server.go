@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/embedding"
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/qa"
+)
+
+const (
+	// shutdownTimeout is how long in-flight requests get to finish on shutdown
+	shutdownTimeout = 30 * time.Second
+
+	// maxRequestBody limits the size of JSON request bodies
+	maxRequestBody = 1 << 20
+)
+
+// apiServer serves the question answering pipeline over HTTP
+type apiServer struct {
+	store     database.VectorStore
+	embedder  embedding.Embedder
+	llmClient llm.Generator
+	opts      qa.Options
+	timeout   time.Duration // Per-request deadline
+	history   int           // Maximum number of earlier turns accepted with a question
+}
+
+// askRequest is the body of POST /api/ask and POST /api/search
+type askRequest struct {
+	Question string `json:"question"`
+	Context  int    `json:"context,omitempty"` // Number of contexts, defaults to -context
+	Rule     string `json:"rule,omitempty"`    // Rule filter (e.g., "Rule 14")
+	Stream   bool   `json:"stream,omitempty"`  // Stream the answer as server-sent events
+	Format   string `json:"format,omitempty"`  // Play format, "match" or "stroke"; defaults to -format
+
+	// Earlier turns of the conversation, oldest first. The server keeps no
+	// sessions, so clients send the history back with every follow-up.
+	History []models.ConversationTurn `json:"history,omitempty"`
+}
+
+// searchResponse is the body returned by POST /api/search
+type searchResponse struct {
+	Results []models.TextChunk `json:"results"`
+}
+
+// rulesResponse is the body returned by GET /api/rules/{number}
+type rulesResponse struct {
+	Rule   string             `json:"rule"`
+	Chunks []models.TextChunk `json:"chunks"`
+}
+
+// sectionsResponse is the body returned by GET /api/sections
+type sectionsResponse struct {
+	Sections []string `json:"sections"`
+}
+
+// errorBody is the body of every error response
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Handler returns the HTTP routes of the API
+func (s *apiServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("POST /api/ask", s.handleAsk)
+	mux.HandleFunc("POST /api/search", s.handleSearch)
+	mux.HandleFunc("GET /api/rules/{number}", s.handleRule)
+	mux.HandleFunc("GET /api/sections", s.handleSections)
+	return s.withTimeout(mux)
+}
+
+// ListenAndServe serves the API on addr until SIGINT or SIGTERM, then waits
+// for in-flight requests to finish
+func (s *apiServer) ListenAndServe(ctx context.Context, addr string) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		// No WriteTimeout: streamed answers are bounded by the request timeout instead
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		log.Printf("Serving golf rules API on %s", addr)
+		errChan <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down, waiting for in-flight requests...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down cleanly: %w", err)
+	}
+	return nil
+}
+
+// withTimeout bounds every request by the server's request timeout
+func (s *apiServer) withTimeout(next http.Handler) http.Handler {
+	if s.timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *apiServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleAsk answers a question, as JSON or as server-sent events when streaming
+func (s *apiServer) handleAsk(w http.ResponseWriter, r *http.Request) {
+	req, opts, ok := s.decodeAskRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Stream || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.streamAnswer(w, r, req.Question, opts)
+		return
+	}
+
+	response, err := qa.Answer(r.Context(), req.Question, s.store, s.embedder, s.llmClient, opts, nil)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// streamAnswer streams an answer as server-sent events: a "token" event for
+// every piece of the answer, then a "done" event carrying the full response,
+// or an "error" event if generation fails
+func (s *apiServer) streamAnswer(w http.ResponseWriter, r *http.Request, question string, opts qa.Options) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorBody{Error: "streaming is not supported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	onToken := func(token string) error {
+		if err := writeEvent(w, "token", map[string]string{"text": token}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	response, err := qa.Answer(r.Context(), question, s.store, s.embedder, s.llmClient, opts, onToken)
+	if err != nil {
+		writeEvent(w, "error", errorBody{Error: err.Error()})
+	} else {
+		writeEvent(w, "done", response)
+	}
+	flusher.Flush()
+}
+
+// handleSearch returns the contexts retrieved for a question without answering
+// it, rewriting follow-up questions like handleAsk
+func (s *apiServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	req, opts, ok := s.decodeAskRequest(w, r)
+	if !ok {
+		return
+	}
+
+	question, err := llm.RewriteQuery(r.Context(), s.llmClient, opts.History, req.Question)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	chunks, err := qa.Retrieve(r.Context(), question, s.store, s.embedder, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if chunks == nil {
+		chunks = []models.TextChunk{}
+	}
+	writeJSON(w, http.StatusOK, searchResponse{Results: chunks})
+}
+
+// handleRule returns the chunks of a rule, given as "13" or "Rule 13"
+func (s *apiServer) handleRule(w http.ResponseWriter, r *http.Request) {
+	rule := strings.TrimSpace(r.PathValue("number"))
+	if !strings.HasPrefix(rule, "Rule ") {
+		rule = "Rule " + rule
+	}
+
+	chunks, err := s.store.QueryByRuleNumber(r.Context(), rule)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if len(chunks) == 0 {
+		writeJSON(w, http.StatusNotFound, errorBody{Error: fmt.Sprintf("%s not found", rule)})
+		return
+	}
+	writeJSON(w, http.StatusOK, rulesResponse{Rule: rule, Chunks: chunks})
+}
+
+// handleSections lists the rule sections in the index
+func (s *apiServer) handleSections(w http.ResponseWriter, r *http.Request) {
+	sections, err := s.store.GetRuleSections(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if sections == nil {
+		sections = []string{}
+	}
+	writeJSON(w, http.StatusOK, sectionsResponse{Sections: sections})
+}
+
+// decodeAskRequest reads an askRequest and the query options it selects,
+// writing an error response and returning false if it is invalid
+func (s *apiServer) decodeAskRequest(w http.ResponseWriter, r *http.Request) (askRequest, qa.Options, bool) {
+	var req askRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return req, qa.Options{}, false
+	}
+
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "question is required"})
+		return req, qa.Options{}, false
+	}
+	if req.Context < 0 {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "context must not be negative"})
+		return req, qa.Options{}, false
+	}
+
+	opts := s.opts
+	if req.Context > 0 {
+		opts.ContextLimit = req.Context
+	}
+	if req.Rule != "" {
+		opts.RuleFilter = req.Rule
+	}
+	opts.History = llm.TrimHistory(req.History, s.history)
+
+	if req.Format != "" {
+		format, err := parsePlayFormat(req.Format)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return req, qa.Options{}, false
+		}
+		opts.PlayFormat = format
+	}
+
+	return req, opts, true
+}
+
+// writeJSON writes body as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Warning: failed to write response: %v", err)
+	}
+}
+
+// writeError reports a failed request, distinguishing timeouts from other errors
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	log.Printf("%s %s failed: %v", r.Method, r.URL.Path, err)
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+// writeEvent writes a server-sent event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
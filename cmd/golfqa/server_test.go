@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/embedding"
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/qa"
+	"golf-rules-rag/internal/retrieval"
+)
+
+// newTestServer returns an API server over a file store holding a few
+// rules, answering with the fake providers
+func newTestServer(t *testing.T) *apiServer {
+	t.Helper()
+	ctx := context.Background()
+	embedder := embedding.NewFakeEmbedder(64)
+
+	store, err := database.NewFileStore(t.TempDir() + "/test.index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RegisterIndex(ctx, models.IndexInfo{Embedding: models.EmbeddingInfo{Dimension: 64}}); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := embedder.EmbedBatch(ctx, []models.TextChunk{
+		{Content: "The flagstick may be left in the hole when a ball is played from the putting green.",
+			Metadata: models.Metadata{Section: "Rule 13", Title: "Putting Greens", Subsection: "13.2a",
+				Hierarchy: "Rule 13 > 13.2 > 13.2a", ParentRule: "Rule 13"}},
+		{Content: "A ball to be lifted must be marked before it is lifted.",
+			Metadata: models.Metadata{Section: "Rule 14", Title: "Procedures for Ball", Subsection: "14.1a",
+				Hierarchy: "Rule 14 > 14.1 > 14.1a", ParentRule: "Rule 14"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range chunks {
+		if err := store.StoreTextChunk(ctx, &chunks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &apiServer{
+		store:     store,
+		embedder:  embedder,
+		llmClient: llm.NewFakeLLM(),
+		opts:      qa.Options{ContextLimit: 2, Retrieval: retrieval.StrategyVector},
+		history:   llm.DefaultMaxTurns,
+	}
+}
+
+// serve sends a request to the server's routes and returns the recorded response
+func serve(s *apiServer, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAskBadRequest(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name string
+		body string
+		want string // Start of the error message
+	}{
+		{"malformed body", `{"question":`, "invalid request body"},
+		{"unknown field", `{"question": "Can I leave the flagstick in?", "flagstick": true}`, "invalid request body"},
+		{"empty question", `{"question": "  "}`, "question is required"},
+		{"negative context", `{"question": "Can I leave the flagstick in?", "context": -1}`, "context must not be negative"},
+		{"bad format", `{"question": "Can I leave the flagstick in?", "format": "foursomes"}`, "unknown play format"},
+	}
+
+	for _, tt := range tests {
+		for _, path := range []string{"/api/ask", "/api/search"} {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				resp := serve(s, http.MethodPost, path, tt.body, nil)
+
+				var body errorBody
+				if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+					t.Fatalf("failed to decode error body %q: %v", resp.Body.String(), err)
+				}
+				if resp.Code != http.StatusBadRequest || !strings.HasPrefix(body.Error, tt.want) {
+					t.Errorf("got %d %q, want 400 %q", resp.Code, body.Error, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRule(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		path   string
+		status int
+		rule   string
+	}{
+		{"/api/rules/13", http.StatusOK, "Rule 13"},
+		{"/api/rules/Rule%2014", http.StatusOK, "Rule 14"},
+		{"/api/rules/99", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp := serve(s, http.MethodGet, tt.path, "", nil)
+			if resp.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.Code, tt.status, resp.Body.String())
+			}
+
+			if tt.status == http.StatusNotFound {
+				var body errorBody
+				if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil || body.Error != "Rule 99 not found" {
+					t.Errorf("error body = %q, want \"Rule 99 not found\"", resp.Body.String())
+				}
+				return
+			}
+
+			var body rulesResponse
+			if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Rule != tt.rule || len(body.Chunks) != 1 || body.Chunks[0].Metadata.Section != tt.rule {
+				t.Errorf("got %+v, want the chunk of %s", body, tt.rule)
+			}
+		})
+	}
+}
+
+// sseEvent is a server-sent event read back from a response
+type sseEvent struct {
+	name string
+	data string
+}
+
+// parseEvents splits a server-sent event stream into its events
+func parseEvents(t *testing.T, stream string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(stream), "\n\n") {
+		name, data, ok := strings.Cut(block, "\n")
+		if !ok || !strings.HasPrefix(name, "event: ") || !strings.HasPrefix(data, "data: ") {
+			t.Fatalf("malformed event %q", block)
+		}
+		events = append(events, sseEvent{strings.TrimPrefix(name, "event: "), strings.TrimPrefix(data, "data: ")})
+	}
+	return events
+}
+
+func TestAskStream(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+	}{
+		{"stream field", `{"question": "Can I leave the flagstick in the hole?", "stream": true}`, nil},
+		{"accept header", `{"question": "Can I leave the flagstick in the hole?"}`,
+			http.Header{"Accept": {"text/event-stream"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serve(s, http.MethodPost, "/api/ask", tt.body, tt.header)
+			if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("got %d %q, want 200 text/event-stream", resp.Code, resp.Header().Get("Content-Type"))
+			}
+
+			events := parseEvents(t, resp.Body.String())
+			if len(events) < 2 {
+				t.Fatalf("got %d events, want tokens then done", len(events))
+			}
+
+			var streamed strings.Builder
+			for _, event := range events[:len(events)-1] {
+				if event.name != "token" {
+					t.Fatalf("got a %q event before the end, want only tokens", event.name)
+				}
+				var token map[string]string
+				if err := json.Unmarshal([]byte(event.data), &token); err != nil {
+					t.Fatal(err)
+				}
+				streamed.WriteString(token["text"])
+			}
+
+			done := events[len(events)-1]
+			if done.name != "done" {
+				t.Fatalf("last event = %q, want done", done.name)
+			}
+			var response models.Response
+			if err := json.Unmarshal([]byte(done.data), &response); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(response.Answer, "Fake answer to: Can I leave the flagstick in the hole?") ||
+				streamed.String() != response.Answer {
+				t.Errorf("streamed %q, done with %q; want the same answer", streamed.String(), response.Answer)
+			}
+			if len(response.Sources) == 0 {
+				t.Error("done event carries no sources")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/retrieval"
+)
+
+var (
+	// rulePrefixPattern matches the ways a rule number is introduced, e.g.
+	// "Rule ", "Rules", "R" or "R."
+	rulePrefixPattern = regexp.MustCompile(`^(?:rules?|r)\.?\s*`)
+
+	// ruleNumberPattern matches a normalised rule number such as 16, 16.1,
+	// 16.1c, 16.1c(1), 16.1c(1)(a) or 16.1c1, capturing the rule, subsection
+	// and clauses
+	ruleNumberPattern = regexp.MustCompile(`^(\d+)(?:\.(\d+[a-z]?)((?:\((?:\d+|[a-z])\))*|\d+)?)?$`)
+)
+
+// parseRuleNumber reads the identifier of a rule or of a part of one at any
+// depth, such as "Rule 16.1c", "R16.1c", "16-1c" or "14.3c(1)(a)", and
+// returns its number, e.g. "16.1c"
+func parseRuleNumber(spec string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	s = rulePrefixPattern.ReplaceAllString(s, "")
+	s = strings.Join(strings.Fields(s), "")
+	s = strings.ReplaceAll(s, "-", ".")
+
+	match := ruleNumberPattern.FindStringSubmatch(s)
+	if match == nil {
+		return "", fmt.Errorf("invalid rule %q (expected e.g. '16', '16.1', '16.1c' or '14.3c(1)')", spec)
+	}
+
+	number := match[1]
+	if match[2] != "" {
+		number += "." + match[2]
+	}
+	switch clauses := match[3]; {
+	case clauses == "":
+	case strings.HasPrefix(clauses, "("):
+		number += clauses
+	default:
+		number += "(" + clauses + ")"
+	}
+	return number, nil
+}
+
+// runShowMode prints the stored text of a rule, section or subsection
+// verbatim, in hierarchy order, followed by the rules it references and the
+// parts of the rules that reference it
+func runShowMode(ctx context.Context, store database.VectorStore, spec string) error {
+	number, err := parseRuleNumber(spec)
+	if err != nil {
+		return err
+	}
+
+	graph, err := retrieval.BuildRuleGraph(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	chunks := graph.Resolve(number)
+	if len(chunks) == 0 {
+		return fmt.Errorf("Rule %s not found; run with -list-rules to see the available rules", number)
+	}
+
+	shown := make(map[int]bool, len(chunks))
+	for _, chunk := range chunks {
+		shown[chunk.ID] = true
+	}
+
+	var references []string
+	seenReferences := make(map[string]bool)
+	var referencedBy []models.TextChunk
+	for _, chunk := range chunks {
+		printChunk(chunk)
+
+		for _, ref := range chunk.CrossReferences {
+			refNumber := strings.TrimPrefix(ref, "Rule ")
+			if seenReferences[refNumber] || refNumber == number || citation.IsAncestor(number, refNumber) {
+				continue
+			}
+			seenReferences[refNumber] = true
+			references = append(references, refNumber)
+		}
+
+		for _, source := range graph.ReferencedBy(chunk.ID) {
+			if !shown[source.ID] {
+				shown[source.ID] = true
+				referencedBy = append(referencedBy, source)
+			}
+		}
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		return citation.Less(references[i], references[j])
+	})
+	sort.SliceStable(referencedBy, func(i, j int) bool {
+		return citation.Less(citation.RuleKey(&referencedBy[i]), citation.RuleKey(&referencedBy[j]))
+	})
+
+	fmt.Println("References:")
+	if len(references) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, ref := range references {
+		fmt.Println("  Rule " + ref)
+	}
+
+	fmt.Println("Referenced by:")
+	if len(referencedBy) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, source := range referencedBy {
+		fmt.Printf("  %s [Page: %s]\n", source.Metadata.Hierarchy, source.Metadata.Pages())
+	}
+
+	return nil
+}
+
+// printChunk prints the heading and stored text of a chunk
+func printChunk(chunk models.TextChunk) {
+	heading := chunk.Metadata.Hierarchy
+	if title := chunk.Metadata.SubsecTitle; title != "" {
+		heading += " - " + title
+	} else if title := chunk.Metadata.Title; title != "" && chunk.Metadata.Subsection == "" {
+		heading += " - " + title
+	}
+
+	fmt.Printf("%s [Page: %s]\n", heading, chunk.Metadata.Pages())
+	fmt.Println(strings.Repeat("-", len(heading)))
+	fmt.Println(strings.TrimSpace(chunk.Content))
+	fmt.Println()
+}
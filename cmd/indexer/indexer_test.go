@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestCheckIndexName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"default", true},
+		{"rules-2023-nomic", true},
+		{"", true},
+		{"true", false},
+		{"false", false},
+		{"FALSE", false},
+		{"0", false},
+		{"t", false},
+		{"-pdf", false},
+	}
+
+	for _, tt := range tests {
+		if err := checkIndexName(tt.name); (err == nil) != tt.valid {
+			t.Errorf("checkIndexName(%q) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
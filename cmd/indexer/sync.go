@@ -0,0 +1,82 @@
+package main
+
+import (
+	"golf-rules-rag/internal/models"
+)
+
+// indexPlan describes how to bring the stored chunks of a document in line
+// with a freshly processed copy of it
+type indexPlan struct {
+	// Unchanged chunks already carry the embedding stored for them
+	Unchanged []models.TextChunk
+	// Pending chunks are new or changed and still need embedding
+	Pending []models.TextChunk
+	// Stale lists the IDs of stored chunks that no longer exist
+	Stale []int
+
+	Added, Changed, Removed int
+}
+
+// planIndexUpdate compares processed chunks against the chunks already stored
+// for the same document. Chunks are matched by hierarchy path and content hash;
+// within a hierarchy path, unmatched new and old chunks pair up as changes and
+// the remainder count as additions or removals.
+func planIndexUpdate(processed, existing []models.TextChunk) indexPlan {
+	var plan indexPlan
+
+	// Group stored chunks by hierarchy and hash, allowing for duplicates
+	stored := make(map[string]map[string][]models.TextChunk)
+	for _, chunk := range existing {
+		byHash, ok := stored[chunk.Metadata.Hierarchy]
+		if !ok {
+			byHash = make(map[string][]models.TextChunk)
+			stored[chunk.Metadata.Hierarchy] = byHash
+		}
+		byHash[chunk.ContentHash] = append(byHash[chunk.ContentHash], chunk)
+	}
+
+	// Count unmatched new chunks per hierarchy to pair them with stale ones below
+	unmatched := make(map[string]int)
+	seen := make(map[string]bool)
+
+	for _, chunk := range processed {
+		key := chunk.Metadata.Hierarchy + "\x00" + chunk.ContentHash
+		if seen[key] {
+			// Identical chunk under the same path; the store keeps one copy
+			continue
+		}
+		seen[key] = true
+
+		if matches := stored[chunk.Metadata.Hierarchy][chunk.ContentHash]; len(matches) > 0 {
+			chunk.Embedding = matches[0].Embedding
+			plan.Unchanged = append(plan.Unchanged, chunk)
+			stored[chunk.Metadata.Hierarchy][chunk.ContentHash] = matches[1:]
+			continue
+		}
+
+		plan.Pending = append(plan.Pending, chunk)
+		unmatched[chunk.Metadata.Hierarchy]++
+	}
+
+	// Whatever is left in the store has vanished or changed
+	for hierarchy, byHash := range stored {
+		for _, chunks := range byHash {
+			for _, chunk := range chunks {
+				plan.Stale = append(plan.Stale, chunk.ID)
+
+				if unmatched[hierarchy] > 0 {
+					unmatched[hierarchy]--
+					plan.Changed++
+				} else {
+					plan.Removed++
+				}
+			}
+		}
+	}
+
+	for _, count := range unmatched {
+		plan.Added += count
+	}
+
+	return plan
+}
@@ -0,0 +1,363 @@
+package citation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+// How unsupported citations are treated in the answer text
+const (
+	ModeFlag  = "flag"  // Mark them inline
+	ModeStrip = "strip" // Remove them
+	ModeOff   = "off"   // Leave the answer unchanged
+)
+
+// Citation statuses
+const (
+	// Supported citations refer to a retrieved source
+	Supported = "supported"
+	// NotRetrieved citations refer to a real rule that was not among the sources
+	NotRetrieved = "not_retrieved"
+	// Unknown citations refer to no rule in the index
+	Unknown = "unknown"
+)
+
+// numberPattern matches a rule number such as 14, 14.3, 14.3c, 14.3c(1) or 14.3c(1)(a)
+const numberPattern = `\d+(?:\.\d+[a-z]?(?:\((?:\d+|[a-z])\))*)?`
+
+var (
+	// referencePattern matches a citation such as "Rule 14.3c(1)" or a list
+	// such as "Rules 14.3, 16.1 and 17"
+	referencePattern = regexp.MustCompile(`\bRules?\s+` + numberPattern +
+		`(?:(?:\s*,\s*|\s*,?\s+(?:and|or)\s+)` + numberPattern + `)*`)
+
+	numberRe = regexp.MustCompile(numberPattern)
+)
+
+// reference is one rule number cited in a text, and where it is
+type reference struct {
+	number     string
+	start, end int
+}
+
+// group is a run of references introduced by "Rule" or "Rules"
+type group struct {
+	start, end int
+	refs       []reference
+}
+
+// Report is the result of validating the citations of an answer
+type Report struct {
+	Citations []models.Citation
+	Score     float64 // Fraction of citations that are supported
+}
+
+// Unsupported returns the citations that are not supported by the sources
+func (r Report) Unsupported() []models.Citation {
+	var unsupported []models.Citation
+	for _, citation := range r.Citations {
+		if citation.Status != Supported {
+			unsupported = append(unsupported, citation)
+		}
+	}
+	return unsupported
+}
+
+// Extract returns the rule references cited in text, e.g. "Rule 11.2b(1)",
+// without duplicates and in order of first appearance
+func Extract(text string) []string {
+	var references []string
+	seen := make(map[string]bool)
+	for _, g := range findGroups(text) {
+		for _, ref := range g.refs {
+			if !seen[ref.number] {
+				seen[ref.number] = true
+				references = append(references, "Rule "+ref.number)
+			}
+		}
+	}
+	return references
+}
+
+// Validate checks every rule cited in answer against the sources it was
+// generated from and, for citations no source supports, against the rules
+// in store. The score is the fraction of citations supported by the sources;
+// an answer citing no rules scores 1 since nothing it cites is unsupported.
+func Validate(ctx context.Context, store database.VectorStore, answer string, sources []models.TextChunk) (Report, error) {
+	var sourceKeys []string
+	for i := range sources {
+		if key := RuleKey(&sources[i]); key != "" {
+			sourceKeys = append(sourceKeys, key)
+		}
+	}
+
+	// Rules are loaded from the store once, whatever the number of citations
+	catalogue := make(map[string][]string)
+
+	report := Report{Score: 1}
+	supported := 0
+	for _, reference := range Extract(answer) {
+		number := strings.TrimPrefix(reference, "Rule ")
+		citation := models.Citation{Reference: reference, Status: Supported}
+
+		if !CoveredBy(number, sourceKeys) {
+			rule := "Rule " + mainRule(number)
+			keys, ok := catalogue[rule]
+			if !ok {
+				chunks, err := store.QueryByRuleNumber(ctx, rule)
+				if err != nil {
+					return Report{}, fmt.Errorf("failed to look up %s: %w", rule, err)
+				}
+				for i := range chunks {
+					if key := RuleKey(&chunks[i]); key != "" {
+						keys = append(keys, key)
+					}
+				}
+				catalogue[rule] = keys
+			}
+
+			citation.Status = Unknown
+			if CoveredBy(number, keys) {
+				citation.Status = NotRetrieved
+			}
+		}
+
+		if citation.Status == Supported {
+			supported++
+		}
+		report.Citations = append(report.Citations, citation)
+	}
+
+	if len(report.Citations) > 0 {
+		report.Score = float64(supported) / float64(len(report.Citations))
+	}
+
+	return report, nil
+}
+
+// Apply rewrites answer according to mode, marking or removing the citations
+// the report found unsupported
+func Apply(mode, answer string, report Report) (string, error) {
+	switch mode {
+	case ModeFlag:
+		return Flag(answer, report), nil
+	case ModeStrip:
+		return Strip(answer, report), nil
+	case ModeOff, "":
+		return answer, nil
+	default:
+		return "", fmt.Errorf("unknown citation mode %q (expected %q, %q or %q)", mode, ModeFlag, ModeStrip, ModeOff)
+	}
+}
+
+// Flag marks every unsupported citation in answer with its status, e.g.
+// "Rule 99.1 [no such rule]"
+func Flag(answer string, report Report) string {
+	statuses := statusByNumber(report)
+
+	var refs []reference
+	for _, g := range findGroups(answer) {
+		refs = append(refs, g.refs...)
+	}
+
+	// Insert from the end so earlier offsets stay valid
+	for i := len(refs) - 1; i >= 0; i-- {
+		label := Label(statuses[refs[i].number])
+		if label == "" {
+			continue
+		}
+		answer = answer[:refs[i].end] + " [" + label + "]" + answer[refs[i].end:]
+	}
+	return answer
+}
+
+// Strip removes every unsupported citation from answer. Lists of rules keep
+// their supported members, and citations left with nothing to cite are
+// removed along with the parentheses around them.
+func Strip(answer string, report Report) string {
+	statuses := statusByNumber(report)
+	groups := findGroups(answer)
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		g := groups[i]
+
+		var kept []string
+		for _, ref := range g.refs {
+			if status, ok := statuses[ref.number]; !ok || status == Supported {
+				kept = append(kept, ref.number)
+			}
+		}
+		switch {
+		case len(kept) == len(g.refs):
+		case len(kept) == 0:
+			start, end := removalSpan(answer, g.start, g.end)
+			answer = answer[:start] + answer[end:]
+		default:
+			answer = answer[:g.start] + formatGroup(kept) + answer[g.end:]
+		}
+	}
+
+	return answer
+}
+
+// removalSpan widens the span of a citation removed from text to the
+// parentheses around it, if nothing else is inside them, and to the spaces
+// that would otherwise be left doubled or before punctuation
+func removalSpan(text string, start, end int) (int, int) {
+	before := strings.TrimRight(text[:start], " ")
+	after := strings.TrimLeft(text[end:], " ")
+	if strings.HasSuffix(before, "(") && strings.HasPrefix(after, ")") {
+		start, end = len(before)-1, len(text)-len(after)+1
+	}
+
+	if end == len(text) || strings.ContainsRune(" .,;:)", rune(text[end])) {
+		start = len(strings.TrimRight(text[:start], " "))
+	}
+	if start == 0 {
+		end = len(text) - len(strings.TrimLeft(text[end:], " "))
+	}
+	return start, end
+}
+
+// findGroups locates the citations in text
+func findGroups(text string) []group {
+	var groups []group
+	for _, match := range referencePattern.FindAllStringIndex(text, -1) {
+		g := group{start: match[0], end: match[1]}
+
+		// Skip "Rule" or "Rules" before looking for numbers
+		offset := match[0] + strings.IndexFunc(text[match[0]:], func(r rune) bool {
+			return r >= '0' && r <= '9'
+		})
+		for _, loc := range numberRe.FindAllStringIndex(text[offset:match[1]], -1) {
+			g.refs = append(g.refs, reference{
+				number: text[offset+loc[0] : offset+loc[1]],
+				start:  offset + loc[0],
+				end:    offset + loc[1],
+			})
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// formatGroup writes rule numbers back as a citation
+func formatGroup(numbers []string) string {
+	switch len(numbers) {
+	case 0:
+		return ""
+	case 1:
+		return "Rule " + numbers[0]
+	default:
+		return "Rules " + strings.Join(numbers[:len(numbers)-1], ", ") + " and " + numbers[len(numbers)-1]
+	}
+}
+
+// Label describes an unsupported citation status, e.g. "no such rule", or
+// returns "" for supported citations
+func Label(status string) string {
+	switch status {
+	case NotRetrieved:
+		return "not in sources"
+	case Unknown:
+		return "no such rule"
+	default:
+		return ""
+	}
+}
+
+// statusByNumber indexes the report's citation statuses by rule number
+func statusByNumber(report Report) map[string]string {
+	statuses := make(map[string]string, len(report.Citations))
+	for _, citation := range report.Citations {
+		statuses[strings.TrimPrefix(citation.Reference, "Rule ")] = citation.Status
+	}
+	return statuses
+}
+
+// RuleKey returns the rule number a chunk holds the text of, e.g. "13.1c",
+// or "" for chunks outside the numbered rules
+func RuleKey(chunk *models.TextChunk) string {
+	if chunk.Metadata.Subsection != "" {
+		return chunk.Metadata.Subsection
+	}
+	if number, ok := strings.CutPrefix(chunk.Metadata.Section, "Rule "); ok {
+		return number
+	}
+	return ""
+}
+
+// CoveredBy reports whether a rule number such as "14.3c" is backed by one
+// of the chunk keys returned by RuleKey: a chunk of the rule itself, of a
+// part of it, or of a numbered section that contains it. A bare rule chunk,
+// which holds only the rule's title, backs only citations of the whole rule.
+func CoveredBy(number string, keys []string) bool {
+	for _, key := range keys {
+		if key == number || IsAncestor(number, key) {
+			return true
+		}
+		if strings.Contains(key, ".") && IsAncestor(key, number) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAncestor reports whether rule number child lies within parent, e.g.
+// 14.3 within 14 and 14.3c(1) within 14.3c, but not 14 within 1
+func IsAncestor(parent, child string) bool {
+	if len(child) <= len(parent) || !strings.HasPrefix(child, parent) {
+		return false
+	}
+
+	next := child[len(parent)]
+	last := parent[len(parent)-1]
+	switch {
+	case next >= '0' && next <= '9':
+		return false
+	case next >= 'a' && next <= 'z' && last >= 'a' && last <= 'z':
+		return false
+	}
+	return true
+}
+
+// Less orders rule numbers by rule, then by section, then by the rest
+// of the number, so that 2.1 comes before 14.3 and 14.3 before 14.10. Empty
+// numbers come last.
+func Less(a, b string) bool {
+	if a == "" || b == "" {
+		return a != "" && b == ""
+	}
+
+	mainA, restA, _ := strings.Cut(a, ".")
+	mainB, restB, _ := strings.Cut(b, ".")
+	if numA, numB := leadingNumber(mainA), leadingNumber(mainB); numA != numB {
+		return numA < numB
+	}
+	if numA, numB := leadingNumber(restA), leadingNumber(restB); numA != numB {
+		return numA < numB
+	}
+	return restA < restB
+}
+
+// leadingNumber returns the number s starts with, or 0 if it starts with none
+func leadingNumber(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// mainRule returns the rule a number belongs to, e.g. "14" for "14.3c(1)"
+func mainRule(number string) string {
+	main, _, _ := strings.Cut(number, ".")
+	return main
+}
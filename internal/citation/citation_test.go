@@ -0,0 +1,148 @@
+package citation
+
+import (
+	"reflect"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Take relief under Rule 16.1c.", []string{"Rule 16.1c"}},
+		{"See Rule 11.2b(1) and Rule 14.3c(1)(a).", []string{"Rule 11.2b(1)", "Rule 14.3c(1)(a)"}},
+		{"Rules 14.3, 16.1 and 17 apply.", []string{"Rule 14.3", "Rule 16.1", "Rule 17"}},
+		{"Rules 13.1 or 13.2 may apply.", []string{"Rule 13.1", "Rule 13.2"}},
+		{"Rules 9.4, 9.5, and 10 apply.", []string{"Rule 9.4", "Rule 9.5", "Rule 10"}},
+		{"Rule 14 and then Rule 14 again.", []string{"Rule 14"}},
+		{"Play the ball as it lies.", nil},
+		{"Ruled out in 2019.", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Extract(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// report builds a report with the given statuses by rule number
+func report(statuses map[string]string) Report {
+	var r Report
+	for number, status := range statuses {
+		r.Citations = append(r.Citations, models.Citation{Reference: "Rule " + number, Status: status})
+	}
+	return r
+}
+
+func TestFlag(t *testing.T) {
+	statuses := report(map[string]string{
+		"14.3": Supported,
+		"99.1": Unknown,
+		"17":   NotRetrieved,
+	})
+
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"Drop under Rule 14.3.", "Drop under Rule 14.3."},
+		{"See Rule 99.1.", "See Rule 99.1 [no such rule]."},
+		{"Rules 14.3, 99.1 and 17 apply.", "Rules 14.3, 99.1 [no such rule] and 17 [not in sources] apply."},
+		{"(Rule 17)", "(Rule 17 [not in sources])"},
+		{"No rules cited ()", "No rules cited ()"},
+	}
+
+	for _, tt := range tests {
+		if got := Flag(tt.answer, statuses); got != tt.want {
+			t.Errorf("Flag(%q) = %q, want %q", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	statuses := report(map[string]string{
+		"14.3": Supported,
+		"16.1": Supported,
+		"99.1": Unknown,
+		"17":   NotRetrieved,
+	})
+
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{"supported", "Drop under Rule 14.3.", "Drop under Rule 14.3."},
+		{"in parentheses", "You may drop the ball (Rule 99.1).", "You may drop the ball."},
+		{"in parentheses mid-sentence", "You may drop (Rule 99.1) within one club-length.", "You may drop within one club-length."},
+		{"in a sentence", "See Rule 99.1 for details.", "See for details."},
+		{"at the start", "Rule 99.1 does not exist.", "does not exist."},
+		{"at the end", "This is covered by Rule 99.1", "This is covered by"},
+		{"list keeps supported", "Rules 14.3, 99.1 and 16.1 apply.", "Rules 14.3 and 16.1 apply."},
+		{"list down to one", "Rules 14.3 and 17 apply.", "Rule 14.3 apply."},
+		{"list emptied", "Relief is free (Rules 99.1 and 17).", "Relief is free."},
+		{"parentheses with other text", "Relief is free (see Rule 99.1).", "Relief is free (see)."},
+		{"unrelated empty parentheses", "Call f() first (Rule 99.1), then mark  the ball ( ).",
+			"Call f() first, then mark  the ball ( )."},
+		{"unrelated space before punctuation", "Is it allowed ? Yes (Rule 14.3).", "Is it allowed ? Yes (Rule 14.3)."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Strip(tt.answer, statuses); got != tt.want {
+				t.Errorf("Strip(%q) = %q, want %q", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAncestor(t *testing.T) {
+	tests := []struct {
+		parent, child string
+		want          bool
+	}{
+		{"14", "14.3", true},
+		{"14", "14.3c(1)(a)", true},
+		{"14.3", "14.3c", true},
+		{"14.3c", "14.3c(1)", true},
+		{"14.3c(1)", "14.3c(1)(a)", true},
+		{"1", "14", false},
+		{"1", "14.3", false},
+		{"14.3", "14.31", false},
+		{"14.3c", "14.3cd", false},
+		{"14.3", "14.3", false},
+		{"14.3c", "14.3", false},
+		{"14", "1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAncestor(tt.parent, tt.child); got != tt.want {
+			t.Errorf("IsAncestor(%q, %q) = %v, want %v", tt.parent, tt.child, got, tt.want)
+		}
+	}
+}
+
+func TestCoveredBy(t *testing.T) {
+	tests := []struct {
+		number string
+		keys   []string
+		want   bool
+	}{
+		{"14.3c", []string{"14.3c"}, true},
+		{"14.3c(1)", []string{"14.3"}, true},
+		{"14", []string{"14.3c"}, true},
+		{"14.3", []string{"14"}, false},
+		{"1.2", []string{"14.3"}, false},
+		{"14.3", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := CoveredBy(tt.number, tt.keys); got != tt.want {
+			t.Errorf("CoveredBy(%q, %q) = %v, want %v", tt.number, tt.keys, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,597 @@
+package database
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// DefaultFileStorePath is where the file-backed store keeps its index
+	DefaultFileStorePath = "golfrag.index"
+
+	fileStoreVersion = 2
+)
+
+// FileStore is an in-process VectorStore persisted to a single file.
+// Similarity search is a brute-force cosine scan, which is fast enough
+// for the few thousand chunks in a rulebook. The file holds any number of
+// named indexes; a FileStore is scoped to one of them.
+type FileStore struct {
+	Path  string
+	Index string
+
+	*fileState
+}
+
+// fileState is the in-memory contents of the index file, shared by every
+// view returned from WithIndex
+type fileState struct {
+	mu      sync.RWMutex
+	nextID  int
+	indexes map[string]*storedIndex
+	dirty   bool
+}
+
+// storedIndex is a named index and its chunks
+type storedIndex struct {
+	Info   models.IndexInfo
+	Chunks []models.TextChunk
+}
+
+// fileIndex is the on-disk representation of a FileStore
+type fileIndex struct {
+	Version int
+	NextID  int
+	Indexes map[string]*storedIndex
+
+	// Version 1 files held a single unnamed index
+	Chunks    []models.TextChunk
+	Embedding *models.EmbeddingInfo
+}
+
+// NewFileStore opens the file-backed store at path, loading any existing
+// index file. The store is scoped to the default index.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		path = DefaultFileStorePath
+	}
+
+	store := &FileStore{
+		Path:      path,
+		Index:     DefaultIndexName,
+		fileState: &fileState{nextID: 1, indexes: make(map[string]*storedIndex)},
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index file: %w", err)
+	}
+	defer f.Close()
+
+	var index fileIndex
+	if err := gob.NewDecoder(f).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode index file %s: %w", path, err)
+	}
+
+	switch index.Version {
+	case fileStoreVersion:
+		if index.Indexes != nil {
+			store.indexes = index.Indexes
+		}
+	case 1:
+		// The single index of a version 1 file becomes the default index
+		if legacy := upgradeFileIndex(&index); legacy != nil {
+			store.indexes[DefaultIndexName] = legacy
+		}
+		store.dirty = true
+	default:
+		return nil, fmt.Errorf("unsupported index file version %d (expected %d)", index.Version, fileStoreVersion)
+	}
+	store.nextID = index.NextID
+
+	return store, nil
+}
+
+// upgradeFileIndex converts the contents of a version 1 index file into a
+// named index, or returns nil if nothing was indexed
+func upgradeFileIndex(index *fileIndex) *storedIndex {
+	info := models.IndexInfo{Name: DefaultIndexName, CreatedAt: time.Now()}
+	switch {
+	case index.Embedding != nil:
+		info.Embedding = *index.Embedding
+	case len(index.Chunks) > 0:
+		info.Embedding.Dimension = len(index.Chunks[0].Embedding)
+	default:
+		return nil
+	}
+	return &storedIndex{Info: info, Chunks: index.Chunks}
+}
+
+// WithIndex returns a view of the store scoped to the named index
+func (s *FileStore) WithIndex(name string) VectorStore {
+	return &FileStore{Path: s.Path, Index: name, fileState: s.fileState}
+}
+
+// Initialize makes sure the directory holding the index exists
+func (s *FileStore) Initialize(ctx context.Context) error {
+	dir := filepath.Dir(s.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	return nil
+}
+
+// CheckSchema is a no-op; the index file format version is checked when it is loaded
+func (s *FileStore) CheckSchema(ctx context.Context) error {
+	return nil
+}
+
+// IndexInfo returns the registry entry of the index the store is scoped to
+func (s *FileStore) IndexInfo(ctx context.Context) (*models.IndexInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index := s.indexes[s.Index]
+	if index == nil {
+		return nil, nil
+	}
+	info := index.info()
+	return &info, nil
+}
+
+// RegisterIndex creates the index the store is scoped to, or updates its
+// edition and chunking parameters if it exists. An existing index must have
+// been built with the same embedding model.
+func (s *FileStore) RegisterIndex(ctx context.Context, info models.IndexInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.indexes[s.Index]
+	if index == nil {
+		index = &storedIndex{Info: models.IndexInfo{Name: s.Index, CreatedAt: time.Now()}}
+		s.indexes[s.Index] = index
+	} else if err := CheckEmbeddingInfo(&index.Info.Embedding, info.Embedding); err != nil {
+		return fmt.Errorf("index %q: %w", s.Index, err)
+	}
+
+	index.Info.Edition = info.Edition
+	index.Info.Embedding = info.Embedding
+	index.Info.ChunkSize = info.ChunkSize
+	index.Info.ChunkOverlap = info.ChunkOverlap
+	s.dirty = true
+
+	return nil
+}
+
+// ListIndexes returns every index in the file
+func (s *FileStore) ListIndexes(ctx context.Context) ([]models.IndexInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	indexes := make([]models.IndexInfo, 0, len(s.indexes))
+	for _, index := range s.indexes {
+		indexes = append(indexes, index.info())
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+
+	return indexes, nil
+}
+
+// StoreTextChunk adds a text chunk to the in-memory index, replacing any
+// chunk with the same document, hierarchy and content hash
+func (s *FileStore) StoreTextChunk(ctx context.Context, chunk *models.TextChunk) error {
+	if len(chunk.Embedding) == 0 {
+		return fmt.Errorf("chunk has no embedding")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.indexes[s.Index]
+	if index == nil || len(chunk.Embedding) != index.Info.Embedding.Dimension {
+		return fmt.Errorf("%w: index %q does not exist or does not hold %d-dimension embeddings",
+			ErrEmbeddingMismatch, s.Index, len(chunk.Embedding))
+	}
+
+	stored := *chunk
+	s.dirty = true
+
+	for i := range index.Chunks {
+		existing := &index.Chunks[i]
+		if existing.DocumentID == chunk.DocumentID &&
+			existing.Metadata.Hierarchy == chunk.Metadata.Hierarchy &&
+			existing.ContentHash == chunk.ContentHash {
+			stored.ID = existing.ID
+			*existing = stored
+			return nil
+		}
+	}
+
+	stored.ID = s.nextID
+	s.nextID++
+	index.Chunks = append(index.Chunks, stored)
+
+	return nil
+}
+
+// GetDocumentChunks returns every chunk stored for a document, including embeddings
+func (s *FileStore) GetDocumentChunks(ctx context.Context, documentID string) ([]models.TextChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chunks []models.TextChunk
+	for _, chunk := range s.chunks() {
+		if chunk.DocumentID == documentID {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	return chunks, nil
+}
+
+// ListChunks returns every chunk in the index, without embeddings,
+// ordered by hierarchy and subsection
+func (s *FileStore) ListChunks(ctx context.Context) ([]models.TextChunk, error) {
+	return s.queryOrdered(func(chunk *models.TextChunk) bool { return true }), nil
+}
+
+// DeleteTextChunks removes the chunks with the given IDs
+func (s *FileStore) DeleteTextChunks(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	remove := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.indexes[s.Index]
+	if index == nil {
+		return nil
+	}
+
+	kept := index.Chunks[:0]
+	for _, chunk := range index.Chunks {
+		if !remove[chunk.ID] {
+			kept = append(kept, chunk)
+		}
+	}
+	index.Chunks = kept
+	s.dirty = true
+
+	return nil
+}
+
+// QuerySimilar finds chunks similar to the query embedding
+func (s *FileStore) QuerySimilar(ctx context.Context, embedding []float64, limit int) ([]models.TextChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rankByDistance(embedding, limit, nil, nil), nil
+}
+
+// QuerySimilarWithStructure restricts the similarity search to rules referenced in the query
+func (s *FileStore) QuerySimilarWithStructure(ctx context.Context, embedding []float64, query string, limit int) ([]models.TextChunk, error) {
+	ruleReferences := extractQueryRuleReferences(query)
+	if len(ruleReferences) == 0 {
+		return s.QuerySimilar(ctx, embedding, limit)
+	}
+
+	filter := func(chunk *models.TextChunk) bool {
+		if contains(ruleReferences, chunk.Metadata.Section) || contains(ruleReferences, chunk.Metadata.ParentRule) {
+			return true
+		}
+		for _, ref := range chunk.CrossReferences {
+			if contains(ruleReferences, ref) {
+				return true
+			}
+		}
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rankByDistance(embedding, limit, filter, nil), nil
+}
+
+// QuerySimilarWithTerms ranks chunks containing golf terms ahead of pure similarity
+func (s *FileStore) QuerySimilarWithTerms(ctx context.Context, embedding []float64, terms []string, limit int) ([]models.TextChunk, error) {
+	// Match the Postgres scoring: 0.5 for every term found in the content
+	score := func(chunk *models.TextChunk) float64 {
+		content := strings.ToLower(chunk.Content)
+		var termScore float64
+		for _, term := range terms {
+			if strings.Contains(content, strings.ToLower(term)) {
+				termScore += 0.5
+			}
+		}
+		return termScore
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rankByDistance(embedding, limit, nil, score), nil
+}
+
+// QueryFullText ranks chunks by BM25 relevance to the query, matching any of its words
+func (s *FileStore) QueryFullText(ctx context.Context, query string, limit int) ([]models.TextChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.chunks()
+	scores := bm25Scores(stored, searchTerms(query))
+
+	var matches []int
+	for i, score := range scores {
+		if score > 0 {
+			matches = append(matches, i)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i]] > scores[matches[j]]
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	chunks := make([]models.TextChunk, 0, len(matches))
+	for _, i := range matches {
+		chunks = append(chunks, withoutEmbedding(stored[i]))
+	}
+
+	return chunks, nil
+}
+
+// QueryByRuleNumber finds chunks for a specific rule
+func (s *FileStore) QueryByRuleNumber(ctx context.Context, ruleNumber string) ([]models.TextChunk, error) {
+	return s.queryOrdered(func(chunk *models.TextChunk) bool {
+		return chunk.Metadata.Section == ruleNumber || chunk.Metadata.ParentRule == ruleNumber
+	}), nil
+}
+
+// QueryByRuleReference finds chunks that reference a specific rule
+func (s *FileStore) QueryByRuleReference(ctx context.Context, ruleRef string) ([]models.TextChunk, error) {
+	return s.queryOrdered(func(chunk *models.TextChunk) bool {
+		return contains(chunk.CrossReferences, ruleRef)
+	}), nil
+}
+
+// GetDefinitionTerms returns the terms defined in the index, in alphabetical order
+func (s *FileStore) GetDefinitionTerms(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, chunk := range s.chunks() {
+		term := chunk.Metadata.Title
+		if chunk.Metadata.ChunkType == models.ChunkTypeDefinition && term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	sort.Strings(terms)
+
+	return terms, nil
+}
+
+// QueryDefinitions finds the definition chunks of the given terms,
+// matching their titles case-insensitively
+func (s *FileStore) QueryDefinitions(ctx context.Context, terms []string) ([]models.TextChunk, error) {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[strings.ToLower(term)] = true
+	}
+
+	return s.queryOrdered(func(chunk *models.TextChunk) bool {
+		return chunk.Metadata.ChunkType == models.ChunkTypeDefinition && wanted[strings.ToLower(chunk.Metadata.Title)]
+	}), nil
+}
+
+// GetRuleSections retrieves all available rule sections
+func (s *FileStore) GetRuleSections(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var sections []string
+	for _, chunk := range s.chunks() {
+		section := chunk.Metadata.Section
+		if section != "" && !seen[section] {
+			seen[section] = true
+			sections = append(sections, section)
+		}
+	}
+	sort.Strings(sections)
+
+	return sections, nil
+}
+
+// Save writes the index to disk if it has changed since it was loaded
+func (s *FileStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated index
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	index := fileIndex{
+		Version: fileStoreVersion,
+		NextID:  s.nextID,
+		Indexes: s.indexes,
+	}
+	if err := gob.NewEncoder(tmp).Encode(&index); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("failed to replace index file: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// Close persists any pending changes
+func (s *FileStore) Close() {
+	if err := s.Save(); err != nil {
+		log.Printf("Warning: failed to save index %s: %v", s.Path, err)
+	}
+}
+
+// rankByDistance orders the chunks accepted by filter by descending boost
+// and then by cosine distance to the embedding. The caller must hold s.mu.
+func (s *FileStore) rankByDistance(embedding []float64, limit int,
+	filter func(*models.TextChunk) bool, boost func(*models.TextChunk) float64) []models.TextChunk {
+
+	type candidate struct {
+		index    int
+		boost    float64
+		distance float64
+	}
+
+	stored := s.chunks()
+
+	var candidates []candidate
+	for i := range stored {
+		chunk := &stored[i]
+		if filter != nil && !filter(chunk) {
+			continue
+		}
+
+		c := candidate{index: i, distance: cosineDistance(embedding, chunk.Embedding)}
+		if boost != nil {
+			c.boost = boost(chunk)
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].boost != candidates[j].boost {
+			return candidates[i].boost > candidates[j].boost
+		}
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	chunks := make([]models.TextChunk, 0, len(candidates))
+	for _, c := range candidates {
+		chunks = append(chunks, withoutEmbedding(stored[c.index]))
+	}
+
+	return chunks
+}
+
+// queryOrdered returns the chunks accepted by filter ordered by hierarchy and subsection
+func (s *FileStore) queryOrdered(filter func(*models.TextChunk) bool) []models.TextChunk {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.chunks()
+
+	var chunks []models.TextChunk
+	for i := range stored {
+		if filter(&stored[i]) {
+			chunks = append(chunks, withoutEmbedding(stored[i]))
+		}
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool {
+		if chunks[i].Metadata.Hierarchy != chunks[j].Metadata.Hierarchy {
+			return chunks[i].Metadata.Hierarchy < chunks[j].Metadata.Hierarchy
+		}
+		return chunks[i].Metadata.Subsection < chunks[j].Metadata.Subsection
+	})
+
+	return chunks
+}
+
+// chunks returns the chunks of the index the store is scoped to. The caller
+// must hold s.mu.
+func (s *FileStore) chunks() []models.TextChunk {
+	if index := s.indexes[s.Index]; index != nil {
+		return index.Chunks
+	}
+	return nil
+}
+
+// info returns the registry entry of the index with its chunk count filled in
+func (index *storedIndex) info() models.IndexInfo {
+	info := index.Info
+	info.ChunkCount = len(index.Chunks)
+	return info
+}
+
+// withoutEmbedding returns a copy of the chunk without its embedding, matching
+// what the Postgres store returns from queries
+func withoutEmbedding(chunk models.TextChunk) models.TextChunk {
+	chunk.Embedding = nil
+	return chunk
+}
+
+// cosineDistance returns 1 - cosine similarity, the same measure as pgvector's <=> operator
+func cosineDistance(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return math.Inf(1)
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// contains checks if a string slice contains a specific value
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
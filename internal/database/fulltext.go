@@ -0,0 +1,140 @@
+package database
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"golf-rules-rag/internal/models"
+)
+
+// BM25 parameters used by the file store's full-text search
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// titleBoost repeats title terms to weight them above body text, like the
+	// 'A' weight on titles in the Postgres search vector
+	titleBoost = 2
+)
+
+// stopWords are skipped when tokenizing, mirroring Postgres' english configuration
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "an": true, "and": true, "any": true,
+	"are": true, "as": true, "at": true, "be": true, "been": true, "before": true, "but": true,
+	"by": true, "can": true, "could": true, "did": true, "do": true, "does": true, "for": true,
+	"from": true, "had": true, "has": true, "have": true, "he": true, "her": true, "his": true,
+	"how": true, "i": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "may": true, "me": true, "my": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "our": true, "she": true, "should": true, "so": true, "such": true,
+	"than": true, "that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "where": true, "which": true,
+	"while": true, "who": true, "will": true, "with": true, "would": true, "you": true, "your": true,
+}
+
+// searchTerms splits text into lower-cased, lightly stemmed terms with stop words removed
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := words[:0]
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+		terms = append(terms, stem(word))
+	}
+	return terms
+}
+
+// stem strips common English inflections so that "drops", "dropped" and
+// "dropping" match "drop". It is deliberately much simpler than Snowball.
+func stem(word string) string {
+	switch {
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		word = strings.TrimSuffix(word, "ing")
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		word = strings.TrimSuffix(word, "ed")
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "es") && !strings.HasSuffix(word, "ses"):
+		return strings.TrimSuffix(word, "s")
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+
+	// Undouble the final consonant left by "dropped" or "dropping"
+	if n := len(word); n > 2 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiouls", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+	return word
+}
+
+// chunkTerms returns the search terms of a chunk's titles and content
+func chunkTerms(chunk *models.TextChunk) []string {
+	titles := searchTerms(chunk.Metadata.Title + " " + chunk.Metadata.SubsecTitle)
+	terms := searchTerms(chunk.Content)
+	for i := 0; i < titleBoost; i++ {
+		terms = append(terms, titles...)
+	}
+	return terms
+}
+
+// bm25Scores scores every chunk against the query terms with Okapi BM25.
+// Chunks matching none of the terms score zero.
+func bm25Scores(chunks []models.TextChunk, queryTerms []string) []float64 {
+	scores := make([]float64, len(chunks))
+	if len(chunks) == 0 || len(queryTerms) == 0 {
+		return scores
+	}
+
+	wanted := make(map[string]bool, len(queryTerms))
+	for _, term := range queryTerms {
+		wanted[term] = true
+	}
+
+	// Term frequencies of the query terms in each chunk, and document frequencies
+	frequencies := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	documentFrequency := make(map[string]int)
+	var totalLength int
+
+	for i := range chunks {
+		terms := chunkTerms(&chunks[i])
+		lengths[i] = len(terms)
+		totalLength += len(terms)
+
+		tf := make(map[string]int)
+		for _, term := range terms {
+			if wanted[term] {
+				tf[term]++
+			}
+		}
+		for term := range tf {
+			documentFrequency[term]++
+		}
+		frequencies[i] = tf
+	}
+
+	averageLength := float64(totalLength) / float64(len(chunks))
+	n := float64(len(chunks))
+
+	for i := range chunks {
+		for term := range wanted {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(lengths[i])/averageLength
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+
+	return scores
+}
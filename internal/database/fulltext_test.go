@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Where do I drop the ball?", []string{"drop", "ball"}},
+		{"dropped, dropping, drops", []string{"drop", "drop", "drop"}},
+		{"Penalty Areas and bunkers", []string{"penalty", "area", "bunker"}},
+		{"Rule 14.3c", []string{"rule", "14", "3c"}},
+		{"the and of", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := searchTerms(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBM25Scores(t *testing.T) {
+	chunks := []models.TextChunk{
+		{Content: "The ball must be dropped in the relief area."},
+		{Content: "A player may remove a loose impediment anywhere."},
+		{Content: "Dropping the ball: the ball is dropped from knee height, and a dropped ball must come to rest."},
+		{Content: "Relief from an abnormal course condition.", Metadata: models.Metadata{Title: "Dropping Ball"}},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		order []int // Chunks expected to score above zero, best first
+	}{
+		{"titles weigh more", "dropped ball", []int{2, 3, 0}},
+		{"single match", "loose impediment", []int{1}},
+		{"no match", "flagstick", nil},
+		{"stop words only", "what is the", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := bm25Scores(chunks, searchTerms(tt.query))
+
+			var matched []int
+			for i, score := range scores {
+				if score > 0 {
+					matched = append(matched, i)
+				}
+			}
+			if len(matched) != len(tt.order) {
+				t.Fatalf("chunks scoring above zero = %v, want %v (scores %v)", matched, tt.order, scores)
+			}
+			for i := 1; i < len(tt.order); i++ {
+				if scores[tt.order[i-1]] <= scores[tt.order[i]] {
+					t.Errorf("chunk %d scored %v, not above chunk %d with %v",
+						tt.order[i-1], scores[tt.order[i-1]], tt.order[i], scores[tt.order[i]])
+				}
+			}
+		})
+	}
+}
+
+func TestBM25ScoresRareTermsWeighMore(t *testing.T) {
+	chunks := []models.TextChunk{
+		{Content: "ball bunker"},
+		{Content: "ball"},
+		{Content: "ball"},
+		{Content: "ball flagstick"},
+	}
+
+	scores := bm25Scores(chunks, searchTerms("bunker ball"))
+	if scores[0] <= scores[1] {
+		t.Errorf("chunk with the rare term scored %v, not above %v", scores[0], scores[1])
+	}
+	if scores[1] != scores[2] {
+		t.Errorf("identical chunks scored %v and %v", scores[1], scores[2])
+	}
+}
+
+func TestFileStoreQueryFullText(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir() + "/test.index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RegisterIndex(ctx, models.IndexInfo{Embedding: models.EmbeddingInfo{Dimension: 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, content := range []string{
+		"A loose impediment may be removed.",
+		"The ball is dropped in the relief area.",
+		"Dropped ball: a ball is dropped.",
+	} {
+		chunk := &models.TextChunk{Content: content, Embedding: []float64{1, 0},
+			Metadata: models.Metadata{Hierarchy: string(rune('a' + i))}}
+		if err := store.StoreTextChunk(ctx, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chunks, err := store.QueryFullText(ctx, "dropped ball", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, chunk := range chunks {
+		got = append(got, chunk.Metadata.Hierarchy)
+		if chunk.Embedding != nil {
+			t.Errorf("chunk %s returned with its embedding", chunk.Metadata.Hierarchy)
+		}
+	}
+	if want := []string{"c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryFullText order = %q, want %q", got, want)
+	}
+
+	chunks, err = store.QueryFullText(ctx, "dropped ball", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 {
+		t.Errorf("QueryFullText with limit 1 returned %d chunks", len(chunks))
+	}
+}
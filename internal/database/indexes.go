@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golf-rules-rag/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// indexColumns is the column list read by scanIndexInfo
+const indexColumns = `i.name, i.edition, i.embedding_provider, i.embedding_model, i.dimension,
+		       i.chunk_size, i.chunk_overlap, i.created_at,
+		       (SELECT count(*) FROM text_chunks c WHERE c.index_name = i.name)`
+
+// IndexInfo returns the registry entry of the index the DB is scoped to
+func (db *DB) IndexInfo(ctx context.Context) (*models.IndexInfo, error) {
+	info, err := scanIndexInfo(db.Pool.QueryRow(ctx, `
+		SELECT `+indexColumns+` FROM indexes i WHERE i.name = $1
+	`, db.Index))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return info, err
+}
+
+// RegisterIndex creates the index the DB is scoped to, or updates its
+// edition and chunking parameters if it exists. An existing index must have
+// been built with the same embedding model.
+func (db *DB) RegisterIndex(ctx context.Context, info models.IndexInfo) error {
+	return pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+		stored, err := scanIndexInfo(tx.QueryRow(ctx, `
+			SELECT `+indexColumns+` FROM indexes i WHERE i.name = $1 FOR UPDATE
+		`, db.Index))
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if stored != nil {
+			if err := CheckEmbeddingInfo(&stored.Embedding, info.Embedding); err != nil {
+				return fmt.Errorf("index %q: %w", db.Index, err)
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO indexes (name, edition, embedding_provider, embedding_model,
+			                     dimension, chunk_size, chunk_overlap)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (name) DO UPDATE SET
+				edition = EXCLUDED.edition,
+				embedding_provider = EXCLUDED.embedding_provider,
+				embedding_model = EXCLUDED.embedding_model,
+				dimension = EXCLUDED.dimension,
+				chunk_size = EXCLUDED.chunk_size,
+				chunk_overlap = EXCLUDED.chunk_overlap
+		`, db.Index, info.Edition, info.Embedding.Provider, info.Embedding.Model,
+			info.Embedding.Dimension, info.ChunkSize, info.ChunkOverlap)
+		if err != nil {
+			return fmt.Errorf("failed to register index %q: %w", db.Index, err)
+		}
+		return nil
+	})
+}
+
+// ListIndexes returns every registered index
+func (db *DB) ListIndexes(ctx context.Context) ([]models.IndexInfo, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+indexColumns+` FROM indexes i ORDER BY i.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []models.IndexInfo
+	for rows.Next() {
+		info, err := scanIndexInfo(rows)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, *info)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return indexes, nil
+}
+
+// scanIndexInfo reads a row selected with indexColumns
+func scanIndexInfo(row pgx.Row) (*models.IndexInfo, error) {
+	var info models.IndexInfo
+	err := row.Scan(
+		&info.Name,
+		&info.Edition,
+		&info.Embedding.Provider,
+		&info.Embedding.Model,
+		&info.Embedding.Dimension,
+		&info.ChunkSize,
+		&info.ChunkOverlap,
+		&info.CreatedAt,
+		&info.ChunkCount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan index: %w", err)
+	}
+	return &info, nil
+}
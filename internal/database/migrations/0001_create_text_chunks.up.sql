@@ -0,0 +1,25 @@
+-- Databases created before migrations existed already have this schema,
+-- so every statement tolerates the objects being present.
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS text_chunks (
+    id SERIAL PRIMARY KEY,
+    content TEXT NOT NULL,
+    page_number INTEGER NOT NULL,
+    section TEXT,
+    title TEXT,
+    hierarchy TEXT,
+    subsection TEXT,
+    subsec_title TEXT,
+    chunk_type TEXT,
+    parent_rule TEXT,
+    cross_references TEXT[],
+    index_terms TEXT[],
+    embedding vector(384) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS text_chunks_embedding_idx ON text_chunks
+USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
+
+CREATE INDEX IF NOT EXISTS text_chunks_section_idx ON text_chunks (section);
+CREATE INDEX IF NOT EXISTS text_chunks_hierarchy_idx ON text_chunks (hierarchy);
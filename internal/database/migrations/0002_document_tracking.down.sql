@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS text_chunks_document_key_idx;
+ALTER TABLE text_chunks DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE text_chunks DROP COLUMN IF EXISTS document_id;
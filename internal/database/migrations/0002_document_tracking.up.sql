@@ -0,0 +1,15 @@
+-- Track the source document and content hash of each chunk so re-indexing
+-- can upsert instead of duplicating rows. Existing rows are hashed in place
+-- and de-duplicated before the unique key is added.
+ALTER TABLE text_chunks ADD COLUMN IF NOT EXISTS document_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE text_chunks ADD COLUMN IF NOT EXISTS content_hash TEXT NOT NULL DEFAULT '';
+
+UPDATE text_chunks SET content_hash = encode(sha256(convert_to(content, 'UTF8')), 'hex')
+WHERE content_hash = '';
+
+DELETE FROM text_chunks a USING text_chunks b
+WHERE a.id > b.id AND a.document_id = b.document_id
+  AND a.hierarchy = b.hierarchy AND a.content_hash = b.content_hash;
+
+CREATE UNIQUE INDEX IF NOT EXISTS text_chunks_document_key_idx
+ON text_chunks (document_id, hierarchy, content_hash);
@@ -0,0 +1,10 @@
+-- This rollback loses data. The embedding column goes back to vector(384),
+-- which cannot hold embeddings of any other dimension, so chunks embedded
+-- with a model of a different dimension are deleted; re-index them after
+-- rolling back.
+DROP TABLE IF EXISTS embedding_metadata;
+DROP INDEX IF EXISTS text_chunks_embedding_idx;
+DELETE FROM text_chunks WHERE vector_dims(embedding) <> 384;
+ALTER TABLE text_chunks ALTER COLUMN embedding TYPE vector(384);
+CREATE INDEX IF NOT EXISTS text_chunks_embedding_idx ON text_chunks
+USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
@@ -0,0 +1,17 @@
+-- The embedding column was hard-coded to vector(384). Drop the dimension so
+-- the indexer can size it for whichever model it probes, and record the
+-- model and dimension an index was built with.
+CREATE TABLE IF NOT EXISTS embedding_metadata (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    dimension INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+DROP INDEX IF EXISTS text_chunks_embedding_idx;
+ALTER TABLE text_chunks ALTER COLUMN embedding TYPE vector;
+
+-- Existing rows keep their dimension; the model that produced them is unknown
+INSERT INTO embedding_metadata (provider, model, dimension)
+SELECT '', '', vector_dims(embedding) FROM text_chunks LIMIT 1;
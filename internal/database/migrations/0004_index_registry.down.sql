@@ -0,0 +1,22 @@
+-- Only the "default" index survives a rollback
+CREATE TABLE IF NOT EXISTS embedding_metadata (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    dimension INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO embedding_metadata (provider, model, dimension)
+SELECT embedding_provider, embedding_model, dimension FROM indexes
+WHERE name = 'default' AND dimension > 0;
+
+DELETE FROM text_chunks WHERE index_name <> 'default';
+
+DROP INDEX IF EXISTS text_chunks_index_section_idx;
+DROP INDEX IF EXISTS text_chunks_document_key_idx;
+ALTER TABLE text_chunks DROP COLUMN index_name;
+DROP TABLE indexes;
+
+CREATE UNIQUE INDEX text_chunks_document_key_idx
+ON text_chunks (document_id, hierarchy, content_hash);
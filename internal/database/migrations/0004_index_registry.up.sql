@@ -0,0 +1,38 @@
+-- Named indexes let several rulebook editions and embedding models share one
+-- database. Each index records its own embedding model and dimension, so the
+-- embedding column can no longer have a fixed dimension. Similarity search is
+-- an exact scan scoped to one index, which is fast for rulebook-sized corpora.
+CREATE TABLE IF NOT EXISTS indexes (
+    name TEXT PRIMARY KEY,
+    edition TEXT NOT NULL DEFAULT '',
+    embedding_provider TEXT NOT NULL DEFAULT '',
+    embedding_model TEXT NOT NULL DEFAULT '',
+    dimension INTEGER NOT NULL DEFAULT 0,
+    chunk_size INTEGER NOT NULL DEFAULT 0,
+    chunk_overlap INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Existing chunks and embedding metadata become the "default" index
+INSERT INTO indexes (name, embedding_provider, embedding_model, dimension)
+SELECT 'default', provider, model, dimension FROM embedding_metadata
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO indexes (name, dimension)
+SELECT 'default', vector_dims(embedding) FROM text_chunks LIMIT 1
+ON CONFLICT (name) DO NOTHING;
+
+DROP TABLE IF EXISTS embedding_metadata;
+
+ALTER TABLE text_chunks ADD COLUMN index_name TEXT NOT NULL DEFAULT 'default'
+    REFERENCES indexes (name) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE text_chunks ALTER COLUMN index_name DROP DEFAULT;
+
+DROP INDEX IF EXISTS text_chunks_embedding_idx;
+ALTER TABLE text_chunks ALTER COLUMN embedding TYPE vector;
+
+DROP INDEX IF EXISTS text_chunks_document_key_idx;
+CREATE UNIQUE INDEX text_chunks_document_key_idx
+ON text_chunks (index_name, document_id, hierarchy, content_hash);
+
+CREATE INDEX text_chunks_index_section_idx ON text_chunks (index_name, section);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS text_chunks_search_idx;
+ALTER TABLE text_chunks DROP COLUMN IF EXISTS search_vector;
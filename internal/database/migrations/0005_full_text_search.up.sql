@@ -0,0 +1,9 @@
+-- Full-text search over chunk titles and content for hybrid retrieval.
+-- Titles are weighted above body text.
+ALTER TABLE text_chunks ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '') || ' ' || coalesce(subsec_title, '')), 'A') ||
+        setweight(to_tsvector('english', content), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS text_chunks_search_idx ON text_chunks USING GIN (search_vector);
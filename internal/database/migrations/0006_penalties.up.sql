@@ -0,0 +1,3 @@
+-- Structured penalty statements extracted from each chunk, as a JSON array
+-- of {"kind", "rule", "text"} objects. Re-index to populate existing chunks.
+ALTER TABLE text_chunks ADD COLUMN IF NOT EXISTS penalties jsonb NOT NULL DEFAULT '[]';
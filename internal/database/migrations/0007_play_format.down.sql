@@ -0,0 +1 @@
+ALTER TABLE text_chunks DROP COLUMN IF EXISTS play_format;
@@ -0,0 +1,3 @@
+-- Play format a chunk is specific to: 'match', 'stroke', or '' when it
+-- applies to both. Re-index to populate existing chunks.
+ALTER TABLE text_chunks ADD COLUMN IF NOT EXISTS play_format text NOT NULL DEFAULT '';
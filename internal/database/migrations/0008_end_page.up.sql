@@ -0,0 +1,3 @@
+-- Last page of a chunk spanning several pages, or 0 when it fits on the
+-- page it starts on. Re-index to populate existing chunks.
+ALTER TABLE text_chunks ADD COLUMN IF NOT EXISTS end_page integer NOT NULL DEFAULT 0;
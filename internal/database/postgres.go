@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
-	"regexp"
+	"log"
+	"strconv"
+	"strings"
 
 	"golf-rules-rag/internal/models"
 
@@ -13,10 +15,19 @@ import (
 )
 
 // DB represents the database connection
+//
+// DB implements VectorStore on top of PostgreSQL with pgvector. Every query
+// is scoped to the named index the DB was opened for.
 type DB struct {
-	Pool *pgxpool.Pool
+	Pool  *pgxpool.Pool
+	Index string
 }
 
+// chunkColumns is the column list read by processRows
+const chunkColumns = `id, content, page_number, section, title, hierarchy,
+               subsection, subsec_title, chunk_type, parent_rule,
+               cross_references, index_terms, penalties, play_format, end_page`
+
 // NewDB creates a new database connection
 func NewDB(connStr string) (*DB, error) {
 	ctx := context.Background()
@@ -30,63 +41,56 @@ func NewDB(connStr string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{Pool: pool}, nil
+	return &DB{Pool: pool, Index: DefaultIndexName}, nil
 }
 
-// Initialize sets up the database tables and indices
-func (db *DB) Initialize(ctx context.Context) error {
-	// Create table for text chunks with vector extension
-	_, err := db.Pool.Exec(ctx, `
-        CREATE TABLE IF NOT EXISTS text_chunks (
-            id SERIAL PRIMARY KEY,
-            content TEXT NOT NULL,
-            page_number INTEGER NOT NULL,
-            section TEXT,
-            title TEXT,
-            hierarchy TEXT,
-            subsection TEXT,
-            subsec_title TEXT,
-            chunk_type TEXT,
-            parent_rule TEXT,
-            cross_references TEXT[],
-            index_terms TEXT[],
-            embedding vector(384) NOT NULL
-        )
-    `)
-	if err != nil {
-		return fmt.Errorf("failed to create text_chunks table: %w", err)
-	}
+// WithIndex returns a view of the database scoped to the named index. The
+// view shares the connection pool, so only one of them should be closed.
+func (db *DB) WithIndex(name string) VectorStore {
+	return &DB{Pool: db.Pool, Index: name}
+}
 
-	// Create vector index
-	_, err = db.Pool.Exec(ctx, `
-		CREATE INDEX IF NOT EXISTS text_chunks_embedding_idx ON text_chunks 
-		USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100)
-	`)
+// Initialize brings the database schema up to date by applying pending migrations
+func (db *DB) Initialize(ctx context.Context) error {
+	applied, err := db.Migrate(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create vector index: %w", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
-
-	// Create indices for better query performance
-	_, err = db.Pool.Exec(ctx, `
-		CREATE INDEX IF NOT EXISTS text_chunks_section_idx ON text_chunks (section);
-		CREATE INDEX IF NOT EXISTS text_chunks_hierarchy_idx ON text_chunks (hierarchy);
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create additional indices: %w", err)
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
 	}
-
 	return nil
 }
 
-// StoreTextChunk stores a text chunk in the database
+// StoreTextChunk stores a text chunk in the database. A chunk with the same
+// document, hierarchy and content hash is updated in place. The embedding
+// must have the dimension registered for the index.
 func (db *DB) StoreTextChunk(ctx context.Context, chunk *models.TextChunk) error {
-	_, err := db.Pool.Exec(ctx, `
+	tag, err := db.Pool.Exec(ctx, `
         INSERT INTO text_chunks (
-            content, page_number, section, title, hierarchy, 
+            content, page_number, section, title, hierarchy,
             subsection, subsec_title, chunk_type, parent_rule,
-            cross_references, index_terms, embedding
+            cross_references, index_terms, embedding,
+            document_id, content_hash, penalties, play_format, end_page, index_name
         )
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12::vector, $13, $14,
+               coalesce($15::jsonb, '[]'), $16, $17, name
+        FROM indexes
+        WHERE name = $18 AND dimension = vector_dims($12::vector)
+        ON CONFLICT (index_name, document_id, hierarchy, content_hash) DO UPDATE SET
+            page_number = EXCLUDED.page_number,
+            section = EXCLUDED.section,
+            title = EXCLUDED.title,
+            subsection = EXCLUDED.subsection,
+            subsec_title = EXCLUDED.subsec_title,
+            chunk_type = EXCLUDED.chunk_type,
+            parent_rule = EXCLUDED.parent_rule,
+            cross_references = EXCLUDED.cross_references,
+            index_terms = EXCLUDED.index_terms,
+            penalties = EXCLUDED.penalties,
+            play_format = EXCLUDED.play_format,
+            end_page = EXCLUDED.end_page,
+            embedding = EXCLUDED.embedding
     `,
 		chunk.Content,
 		chunk.Metadata.PageNumber,
@@ -99,21 +103,98 @@ func (db *DB) StoreTextChunk(ctx context.Context, chunk *models.TextChunk) error
 		chunk.Metadata.ParentRule,
 		chunk.CrossReferences,
 		chunk.IndexTerms,
-		chunk.Embedding)
+		formatVector(chunk.Embedding),
+		chunk.DocumentID,
+		chunk.ContentHash,
+		chunk.Penalties,
+		chunk.Metadata.PlayFormat,
+		chunk.Metadata.EndPage,
+		db.Index)
+	if err != nil {
+		return err
+	}
+
+	// Nothing is inserted when the index is missing or the dimension is wrong
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: index %q does not exist or does not hold %d-dimension embeddings",
+			ErrEmbeddingMismatch, db.Index, len(chunk.Embedding))
+	}
+
+	return nil
+}
+
+// GetDocumentChunks returns every chunk stored for a document, including embeddings
+func (db *DB) GetDocumentChunks(ctx context.Context, documentID string) ([]models.TextChunk, error) {
+	rows, err := db.Pool.Query(ctx, `
+        SELECT `+chunkColumns+`, embedding::text, document_id, content_hash
+        FROM text_chunks
+        WHERE index_name = $1 AND document_id = $2
+        ORDER BY id
+    `, db.Index, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query document chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []models.TextChunk
+	for rows.Next() {
+		var chunk models.TextChunk
+		var embeddingText string
+
+		if err := rows.Scan(chunkFields(&chunk, &embeddingText, &chunk.DocumentID, &chunk.ContentHash)...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if chunk.Embedding, err = parseVector(embeddingText); err != nil {
+			return nil, fmt.Errorf("failed to parse embedding of chunk %d: %w", chunk.ID, err)
+		}
+
+		chunks = append(chunks, chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// ListChunks returns every chunk in the index, without embeddings,
+// ordered by hierarchy and subsection
+func (db *DB) ListChunks(ctx context.Context) ([]models.TextChunk, error) {
+	rows, err := db.Pool.Query(ctx, `
+        SELECT `+chunkColumns+`
+        FROM text_chunks
+        WHERE index_name = $1
+        ORDER BY hierarchy, subsection
+    `, db.Index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunks: %w", err)
+	}
+	return processRows(rows)
+}
 
-	return err
+// DeleteTextChunks removes the chunks with the given IDs
+func (db *DB) DeleteTextChunks(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := db.Pool.Exec(ctx, `DELETE FROM text_chunks WHERE index_name = $1 AND id = ANY($2)`, db.Index, ids)
+	if err != nil {
+		return fmt.Errorf("failed to delete chunks: %w", err)
+	}
+	return nil
 }
 
 // QueryByRuleNumber finds chunks for a specific rule
 func (db *DB) QueryByRuleNumber(ctx context.Context, ruleNumber string) ([]models.TextChunk, error) {
 	rows, err := db.Pool.Query(ctx, `
-        SELECT id, content, page_number, section, title, hierarchy, 
-               subsection, subsec_title, chunk_type, parent_rule,
-               cross_references, index_terms
+        SELECT `+chunkColumns+`
         FROM text_chunks
-        WHERE section = $1 OR parent_rule = $1
+        WHERE index_name = $1 AND (section = $2 OR parent_rule = $2)
         ORDER BY hierarchy, subsection
-    `, ruleNumber)
+    `, db.Index, ruleNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rule reference chunks: %w", err)
 	}
@@ -123,13 +204,11 @@ func (db *DB) QueryByRuleNumber(ctx context.Context, ruleNumber string) ([]model
 // QueryByRuleReference finds chunks that reference a specific rule
 func (db *DB) QueryByRuleReference(ctx context.Context, ruleRef string) ([]models.TextChunk, error) {
 	rows, err := db.Pool.Query(ctx, `
-        SELECT id, content, page_number, section, title, hierarchy, 
-               subsection, subsec_title, chunk_type, parent_rule,
-               cross_references, index_terms
+        SELECT `+chunkColumns+`
         FROM text_chunks
-        WHERE $1 = ANY(cross_references)
+        WHERE index_name = $1 AND $2 = ANY(cross_references)
         ORDER BY hierarchy, subsection
-    `, ruleRef)
+    `, db.Index, ruleRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rule reference chunks: %w", err)
 	}
@@ -139,36 +218,20 @@ func (db *DB) QueryByRuleReference(ctx context.Context, ruleRef string) ([]model
 // QuerySimilarWithStructure Enhanced query function that leverages both vector similarity and rule structure
 func (db *DB) QuerySimilarWithStructure(ctx context.Context, embedding []float64, query string, limit int) ([]models.TextChunk, error) {
 	// Extract rule references from the query
-	rulePattern := regexp.MustCompile(`Rule\s+(\d+)(\.\d+)?([a-z])?(\(\d+\))?`)
-	matches := rulePattern.FindAllStringSubmatch(query, -1)
-
-	var ruleReferences []string
-	for _, match := range matches {
-		if len(match) > 0 {
-			ruleRef := match[0]
-			ruleReferences = append(ruleReferences, ruleRef)
-		}
-	}
+	ruleReferences := extractQueryRuleReferences(query)
 
 	// If rule references found, prioritize those chunks
 	if len(ruleReferences) > 0 {
 		rows, err := db.Pool.Query(ctx, `
-            WITH rule_chunks AS (
-                SELECT id, content, page_number, section, title, hierarchy, 
-                       subsection, subsec_title, chunk_type, parent_rule,
-                       cross_references, index_terms, embedding
-                FROM text_chunks
-                WHERE section = ANY($1) OR parent_rule = ANY($1) OR 
-                      EXISTS (SELECT 1 FROM unnest(cross_references) AS ref 
-                              WHERE ref = ANY($1))
-            )
-            SELECT id, content, page_number, section, title, hierarchy, 
-                   subsection, subsec_title, chunk_type, parent_rule,
-                   cross_references, index_terms
-            FROM rule_chunks
-            ORDER BY embedding <=> $2
-            LIMIT $3
-        `, ruleReferences, embedding, limit)
+            SELECT `+chunkColumns+`
+            FROM text_chunks
+            WHERE index_name = $1 AND (
+                  section = ANY($2) OR parent_rule = ANY($2) OR
+                  EXISTS (SELECT 1 FROM unnest(cross_references) AS ref
+                          WHERE ref = ANY($2)))
+            ORDER BY embedding <=> $3::vector
+            LIMIT $4
+        `, db.Index, ruleReferences, formatVector(embedding), limit)
 		if err != nil {
 			return nil, fmt.Errorf("failed to query similar structure chunks: %w", err)
 		}
@@ -182,66 +245,55 @@ func (db *DB) QuerySimilarWithStructure(ctx context.Context, embedding []float64
 // QuerySimilar finds chunks similar to the query embedding
 func (db *DB) QuerySimilar(ctx context.Context, embedding []float64, limit int) ([]models.TextChunk, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT id, content, page_number, section, title, hierarchy, 
-                       subsection, subsec_title, chunk_type, parent_rule,
-                       cross_references, index_terms, embedding
+		SELECT `+chunkColumns+`
 		FROM text_chunks
-		ORDER BY embedding <=> $1
-		LIMIT $2
-	`, embedding, limit)
+		WHERE index_name = $1
+		ORDER BY embedding <=> $2::vector
+		LIMIT $3
+	`, db.Index, formatVector(embedding), limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query similar chunks: %w", err)
 	}
+	return processRows(rows)
+}
 
-	defer rows.Close()
-
-	var chunks []models.TextChunk
-	for rows.Next() {
-		var (
-			chunk                                                                     models.TextChunk
-			pageNum                                                                   int
-			section, title, hierarchy, subsection, subsecTitle, chunkType, parentRule string
-			crossRefs, indexTerms                                                     []string
-			embeddingCol                                                              []float64
-		)
-
-		if err := rows.Scan(
-			&chunk.ID,
-			&chunk.Content,
-			&pageNum,
-			&section,
-			&title,
-			&hierarchy,
-			&subsection,
-			&subsecTitle,
-			&chunkType,
-			&parentRule,
-			&crossRefs,
-			&indexTerms, &embeddingCol); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		chunk.Metadata = models.Metadata{
-			PageNumber:  pageNum,
-			Section:     section,
-			Title:       title,
-			Hierarchy:   hierarchy,
-			Subsection:  subsection,
-			SubsecTitle: subsecTitle,
-			ChunkType:   chunkType,
-			ParentRule:  parentRule,
-		}
-		chunk.CrossReferences = crossRefs
-		chunk.IndexTerms = indexTerms
-
-		chunks = append(chunks, chunk)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+// QueryFullText ranks chunks by full-text relevance to the query. The query
+// is parsed like plain text but its words are OR-ed, so a question does not
+// have to contain every word of a chunk to match it.
+func (db *DB) QueryFullText(ctx context.Context, query string, limit int) ([]models.TextChunk, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+chunkColumns+`
+		FROM text_chunks,
+		     replace(plainto_tsquery('english', $2)::text, '&', '|')::tsquery AS query
+		WHERE index_name = $1 AND search_vector @@ query
+		ORDER BY ts_rank(search_vector, query, 1) DESC
+		LIMIT $3
+	`, db.Index, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query full text: %w", err)
 	}
+	return processRows(rows)
+}
 
-	return chunks, nil
+// chunkFields returns scan destinations for chunkColumns followed by any extra columns
+func chunkFields(chunk *models.TextChunk, extra ...any) []any {
+	return append([]any{
+		&chunk.ID,
+		&chunk.Content,
+		&chunk.Metadata.PageNumber,
+		nullString{&chunk.Metadata.Section},
+		nullString{&chunk.Metadata.Title},
+		nullString{&chunk.Metadata.Hierarchy},
+		nullString{&chunk.Metadata.Subsection},
+		nullString{&chunk.Metadata.SubsecTitle},
+		nullString{&chunk.Metadata.ChunkType},
+		nullString{&chunk.Metadata.ParentRule},
+		&chunk.CrossReferences,
+		&chunk.IndexTerms,
+		&chunk.Penalties,
+		nullString{&chunk.Metadata.PlayFormat},
+		&chunk.Metadata.EndPage,
+	}, extra...)
 }
 
 func processRows(rows pgx.Rows) ([]models.TextChunk, error) {
@@ -250,39 +302,9 @@ func processRows(rows pgx.Rows) ([]models.TextChunk, error) {
 	var chunks []models.TextChunk
 	for rows.Next() {
 		var chunk models.TextChunk
-		var pageNum int
-		var section, title, hierarchy, subsection, subsecTitle, chunkType, parentRule string
-		var crossRefs, indexTerms []string
-
-		if err := rows.Scan(
-			&chunk.ID,
-			&chunk.Content,
-			&pageNum,
-			&section,
-			&title,
-			&hierarchy,
-			&subsection,
-			&subsecTitle,
-			&chunkType,
-			&parentRule,
-			&crossRefs,
-			&indexTerms); err != nil {
+		if err := rows.Scan(chunkFields(&chunk)...); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
-
-		chunk.Metadata = models.Metadata{
-			PageNumber:  pageNum,
-			Section:     section,
-			Title:       title,
-			Hierarchy:   hierarchy,
-			Subsection:  subsection,
-			SubsecTitle: subsecTitle,
-			ChunkType:   chunkType,
-			ParentRule:  parentRule,
-		}
-		chunk.CrossReferences = crossRefs
-		chunk.IndexTerms = indexTerms
-
 		chunks = append(chunks, chunk)
 	}
 
@@ -297,156 +319,120 @@ func processRows(rows pgx.Rows) ([]models.TextChunk, error) {
 func (db *DB) QuerySimilarWithFilters(ctx context.Context, embedding []float64, limit int,
 	sectionFilter string) ([]models.TextChunk, error) {
 
-	var rows pgx.Rows
-	var err error
-
-	if sectionFilter != "" {
-		// Query with section filter
-		rows, err = db.Pool.Query(ctx, `
-			SELECT id, content, page_number, section, title, hierarchy, 
-                       subsection, subsec_title, chunk_type, parent_rule,
-                       cross_references, index_terms, embedding
-			FROM text_chunks
-			WHERE section LIKE $1
-			ORDER BY embedding <=> $2
-			LIMIT $3
-		`, sectionFilter+"%", embedding, limit)
-	} else {
+	if sectionFilter == "" {
 		// Query without filter
-		rows, err = db.Pool.Query(ctx, `
-			SELECT id, content, page_number, section, title, hierarchy, 
-                       subsection, subsec_title, chunk_type, parent_rule,
-                       cross_references, index_terms, embedding
-			FROM text_chunks
-			ORDER BY embedding <=> $1
-			LIMIT $2
-		`, embedding, limit)
+		return db.QuerySimilar(ctx, embedding, limit)
 	}
 
+	// Query with section filter
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+chunkColumns+`
+		FROM text_chunks
+		WHERE index_name = $1 AND section LIKE $2
+		ORDER BY embedding <=> $3::vector
+		LIMIT $4
+	`, db.Index, sectionFilter+"%", formatVector(embedding), limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query similar chunks: %w", err)
 	}
-	defer rows.Close()
-
-	var chunks []models.TextChunk
-	for rows.Next() {
-		var chunk models.TextChunk
-		var pageNum int
-		var section, title, hierarchy, subsection string
-
-		if err := rows.Scan(&chunk.ID, &chunk.Content, &pageNum, &section, &title, &hierarchy, &subsection); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		chunk.Metadata = models.Metadata{
-			PageNumber: pageNum,
-			Section:    section,
-			Title:      title,
-			Hierarchy:  hierarchy,
-			Subsection: subsection,
-		}
-
-		chunks = append(chunks, chunk)
-	}
-
-	return chunks, nil
+	return processRows(rows)
 }
 
 // QuerySimilarWithTerms enhances vector search with golf-specific term filtering
 func (db *DB) QuerySimilarWithTerms(ctx context.Context, embedding []float64, terms []string, limit int) ([]models.TextChunk, error) {
-	// Convert terms array to SQL array format
-	termParams := make([]interface{}, len(terms)+1)
-	termParams[0] = embedding
+	// The first three parameters are fixed; terms follow from $4
+	termParams := []interface{}{db.Index, formatVector(embedding), limit}
 
 	// Build the SQL query with dynamic term filtering
 	query := `
         WITH term_matches AS (
-            SELECT id, content, page_number, section, title, hierarchy, 
-                   subsection, subsec_title, chunk_type, parent_rule,
-                   cross_references, index_terms, embedding,
-                   (
+            SELECT ` + chunkColumns + `, embedding,
+                   (0
     `
 
 	// Add a score component for each term
-	for i, term := range terms {
-		if i > 0 {
-			query += " + "
-		}
-		query += fmt.Sprintf("CASE WHEN content ILIKE '%%' || $%d || '%%' THEN 0.5 ELSE 0 END", i+2)
-		termParams[i+1] = term
+	for _, term := range terms {
+		termParams = append(termParams, term)
+		query += fmt.Sprintf(" + CASE WHEN content ILIKE '%%' || $%d || '%%' THEN 0.5 ELSE 0 END", len(termParams))
 	}
 
 	// Complete the query
 	query += `
                    ) AS term_score
             FROM text_chunks
+            WHERE index_name = $1
         )
-        SELECT id, content, page_number, section, title, hierarchy, 
-               subsection, subsec_title, chunk_type, parent_rule,
-               cross_references, index_terms
+        SELECT ` + chunkColumns + `
         FROM term_matches
-        ORDER BY term_score DESC, embedding <=> $1
-        LIMIT $` + fmt.Sprintf("%d", len(termParams)+1)
-
-	termParams = append(termParams, limit)
+        ORDER BY term_score DESC, embedding <=> $2::vector
+        LIMIT $3`
 
 	rows, err := db.Pool.Query(ctx, query, termParams...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query similar chunks with terms: %w", err)
 	}
+	return processRows(rows)
+}
+
+// GetDefinitionTerms returns the terms defined in the index, in alphabetical order
+func (db *DB) GetDefinitionTerms(ctx context.Context) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT title FROM text_chunks
+		WHERE index_name = $1 AND chunk_type = $2 AND title != ''
+		ORDER BY title
+	`, db.Index, models.ChunkTypeDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query definition terms: %w", err)
+	}
 	defer rows.Close()
 
-	var chunks []models.TextChunk
+	var terms []string
 	for rows.Next() {
-		var chunk models.TextChunk
-		var pageNum int
-		var section, title, hierarchy, subsection, subsecTitle, chunkType, parentRule string
-		var crossRefs, indexTerms []string
-
-		if err := rows.Scan(
-			&chunk.ID,
-			&chunk.Content,
-			&pageNum,
-			&section,
-			&title,
-			&hierarchy,
-			&subsection,
-			&subsecTitle,
-			&chunkType,
-			&parentRule,
-			&crossRefs,
-			&indexTerms); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		chunk.Metadata = models.Metadata{
-			PageNumber:  pageNum,
-			Section:     section,
-			Title:       title,
-			Hierarchy:   hierarchy,
-			Subsection:  subsection,
-			SubsecTitle: subsecTitle,
-			ChunkType:   chunkType,
-			ParentRule:  parentRule,
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("failed to scan definition term: %w", err)
 		}
-		chunk.CrossReferences = crossRefs
-		chunk.IndexTerms = indexTerms
-
-		chunks = append(chunks, chunk)
+		terms = append(terms, term)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return chunks, nil
+	return terms, nil
+}
+
+// QueryDefinitions finds the definition chunks of the given terms,
+// matching their titles case-insensitively
+func (db *DB) QueryDefinitions(ctx context.Context, terms []string) ([]models.TextChunk, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	lowered := make([]string, len(terms))
+	for i, term := range terms {
+		lowered[i] = strings.ToLower(term)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+        SELECT `+chunkColumns+`
+        FROM text_chunks
+        WHERE index_name = $1 AND chunk_type = $2 AND lower(title) = ANY($3)
+        ORDER BY hierarchy, subsection
+    `, db.Index, models.ChunkTypeDefinition, lowered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query definition chunks: %w", err)
+	}
+	return processRows(rows)
 }
 
 // GetRuleSections retrieves all available rule sections
 func (db *DB) GetRuleSections(ctx context.Context) ([]string, error) {
 	rows, err := db.Pool.Query(ctx, `
-		SELECT DISTINCT section FROM text_chunks WHERE section != '' ORDER BY section
-	`)
+		SELECT DISTINCT section FROM text_chunks
+		WHERE index_name = $1 AND section != ''
+		ORDER BY section
+	`, db.Index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rule sections: %w", err)
 	}
@@ -461,6 +447,10 @@ func (db *DB) GetRuleSections(ctx context.Context) ([]string, error) {
 		sections = append(sections, section)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
 	return sections, nil
 }
 
@@ -468,3 +458,53 @@ func (db *DB) GetRuleSections(ctx context.Context) ([]string, error) {
 func (db *DB) Close() {
 	db.Pool.Close()
 }
+
+// formatVector encodes an embedding in pgvector's text format
+func formatVector(embedding []float64) string {
+	parts := make([]string, len(embedding))
+	for i, v := range embedding {
+		parts[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// parseVector decodes an embedding from pgvector's text format
+func parseVector(text string) ([]float64, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "[")
+	text = strings.TrimSuffix(text, "]")
+	if text == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(text, ",")
+	embedding := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		embedding[i] = v
+	}
+	return embedding, nil
+}
+
+// nullString scans a nullable text column into a string, mapping NULL to ""
+type nullString struct {
+	s *string
+}
+
+// Scan implements sql.Scanner
+func (n nullString) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*n.s = ""
+	case string:
+		*n.s = v
+	case []byte:
+		*n.s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into string", src)
+	}
+	return nil
+}
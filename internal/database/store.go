@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// Supported vector store backends
+	StorePostgres = "postgres"
+	StoreFile     = "file"
+
+	// DefaultIndexName is the index used when none is named
+	DefaultIndexName = "default"
+)
+
+// VectorStore is the storage backend shared by the indexer and the query tool
+type VectorStore interface {
+	// Initialize prepares the backend for storing chunks, migrating its schema if needed
+	Initialize(ctx context.Context) error
+
+	// CheckSchema verifies that the stored schema matches this binary
+	CheckSchema(ctx context.Context) error
+
+	// StoreTextChunk stores a text chunk together with its embedding in the
+	// index, which must already be registered. A chunk with the same document,
+	// hierarchy and content hash is updated in place.
+	StoreTextChunk(ctx context.Context, chunk *models.TextChunk) error
+
+	// WithIndex returns a view of the store scoped to the named index. The
+	// view shares the underlying storage, so only one of them should be closed.
+	WithIndex(name string) VectorStore
+
+	// IndexInfo returns the registry entry of the index the store is scoped
+	// to, or nil if the index has not been created yet
+	IndexInfo(ctx context.Context) (*models.IndexInfo, error)
+
+	// RegisterIndex creates the index the store is scoped to, or updates its
+	// edition and chunking parameters, rejecting an embedding model that does
+	// not match the one the index was built with
+	RegisterIndex(ctx context.Context, info models.IndexInfo) error
+
+	// ListIndexes returns every index in the store ordered by name
+	ListIndexes(ctx context.Context) ([]models.IndexInfo, error)
+
+	// GetDocumentChunks returns every chunk stored for a document, including embeddings
+	GetDocumentChunks(ctx context.Context, documentID string) ([]models.TextChunk, error)
+
+	// ListChunks returns every chunk in the index, without embeddings,
+	// ordered by hierarchy and subsection
+	ListChunks(ctx context.Context) ([]models.TextChunk, error)
+
+	// DeleteTextChunks removes the chunks with the given IDs
+	DeleteTextChunks(ctx context.Context, ids []int) error
+
+	// QuerySimilar finds chunks similar to the query embedding
+	QuerySimilar(ctx context.Context, embedding []float64, limit int) ([]models.TextChunk, error)
+
+	// QuerySimilarWithStructure restricts the similarity search to rules referenced in the query
+	QuerySimilarWithStructure(ctx context.Context, embedding []float64, query string, limit int) ([]models.TextChunk, error)
+
+	// QuerySimilarWithTerms ranks chunks containing golf terms ahead of pure similarity
+	QuerySimilarWithTerms(ctx context.Context, embedding []float64, terms []string, limit int) ([]models.TextChunk, error)
+
+	// QueryFullText ranks chunks by full-text relevance to the query, matching
+	// any of its words
+	QueryFullText(ctx context.Context, query string, limit int) ([]models.TextChunk, error)
+
+	// QueryByRuleNumber finds chunks for a specific rule
+	QueryByRuleNumber(ctx context.Context, ruleNumber string) ([]models.TextChunk, error)
+
+	// QueryByRuleReference finds chunks that reference a specific rule
+	QueryByRuleReference(ctx context.Context, ruleRef string) ([]models.TextChunk, error)
+
+	// GetDefinitionTerms returns the terms defined in the index, in alphabetical order
+	GetDefinitionTerms(ctx context.Context) ([]string, error)
+
+	// QueryDefinitions finds the definition chunks of the given terms,
+	// matching their titles case-insensitively
+	QueryDefinitions(ctx context.Context, terms []string) ([]models.TextChunk, error)
+
+	// GetRuleSections retrieves all available rule sections
+	GetRuleSections(ctx context.Context) ([]string, error)
+
+	// Close releases the resources held by the store
+	Close()
+}
+
+// NewVectorStore opens the vector store backend selected by kind, scoped to
+// the named index
+func NewVectorStore(kind, connStr, path, index string) (VectorStore, error) {
+	if index == "" {
+		index = DefaultIndexName
+	}
+
+	var store VectorStore
+	var err error
+	switch kind {
+	case StorePostgres, "":
+		store, err = NewDB(connStr)
+	case StoreFile:
+		store, err = NewFileStore(path)
+	default:
+		return nil, fmt.Errorf("unknown vector store %q (expected %q or %q)", kind, StorePostgres, StoreFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return store.WithIndex(index), nil
+}
+
+// ErrEmbeddingMismatch is returned when an embedding model does not match the index
+var ErrEmbeddingMismatch = errors.New("embedding model does not match the index")
+
+// CheckEmbeddingInfo verifies that an embedding model matches the one an index
+// was built with. Indexes created before the model was recorded only have
+// their dimension checked.
+func CheckEmbeddingInfo(stored *models.EmbeddingInfo, info models.EmbeddingInfo) error {
+	if stored == nil {
+		return nil
+	}
+	if info.Dimension != 0 && stored.Dimension != info.Dimension {
+		return fmt.Errorf("%w: index has %d-dimension embeddings, %s produces %d",
+			ErrEmbeddingMismatch, stored.Dimension, info.Model, info.Dimension)
+	}
+	if stored.Model != "" && (stored.Model != info.Model || stored.Provider != info.Provider) {
+		return fmt.Errorf("%w: index was built with %s model %q, not %s model %q",
+			ErrEmbeddingMismatch, stored.Provider, stored.Model, info.Provider, info.Model)
+	}
+	return nil
+}
+
+var queryRulePattern = regexp.MustCompile(`Rule\s+(\d+)(\.\d+)?([a-z])?(\(\d+\))?`)
+
+// extractQueryRuleReferences extracts the rule references mentioned in a query
+func extractQueryRuleReferences(query string) []string {
+	matches := queryRulePattern.FindAllStringSubmatch(query, -1)
+
+	var ruleReferences []string
+	for _, match := range matches {
+		if len(match) > 0 {
+			ruleReferences = append(ruleReferences, match[0])
+		}
+	}
+
+	return ruleReferences
+}
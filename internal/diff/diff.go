@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/models"
+)
+
+// Kinds of change between two editions
+const (
+	Added    = "added"
+	Removed  = "removed"
+	Modified = "modified"
+)
+
+// Change describes how one part of a rule differs between two editions
+type Change struct {
+	Key      string // Hierarchy path the chunks were aligned on (e.g., "Rule 13 > 13.1 > 13.1c")
+	Kind     string // Added, Removed or Modified
+	Title    string // Subsection title, or rule title for the rule itself
+	Old, New string // Text in the old and new edition; empty when absent
+	Diff     string // Word-level diff of Old and New for modified parts
+}
+
+// Editions aligns the chunks of two editions by hierarchy path and returns
+// the parts that were added, removed or modified, in rule order. Chunks
+// that share a path, such as a long section split in several pieces, are
+// compared as one text.
+func Editions(oldChunks, newChunks []models.TextChunk) []Change {
+	oldParts := groupByKey(oldChunks)
+	newParts := groupByKey(newChunks)
+
+	var changes []Change
+	for key, oldPart := range oldParts {
+		newPart, ok := newParts[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Kind: Removed, Title: oldPart.title, Old: oldPart.text})
+		case normalize(oldPart.text) != normalize(newPart.text):
+			changes = append(changes, Change{
+				Key:   key,
+				Kind:  Modified,
+				Title: newPart.title,
+				Old:   oldPart.text,
+				New:   newPart.text,
+				Diff:  Text(oldPart.text, newPart.text),
+			})
+		}
+	}
+	for key, newPart := range newParts {
+		if _, ok := oldParts[key]; !ok {
+			changes = append(changes, Change{Key: key, Kind: Added, Title: newPart.title, New: newPart.text})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return pathLess(changes[i].Key, changes[j].Key)
+	})
+
+	return changes
+}
+
+// pathLess orders hierarchy paths as the parts they name appear in the
+// Rules: Rule 2 before Rule 10, a part before the parts within it, and the
+// numbered rules before other paths, such as the definitions
+func pathLess(a, b string) bool {
+	partsA := strings.Split(a, " > ")
+	partsB := strings.Split(b, " > ")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] == partsB[i] {
+			continue
+		}
+
+		numberA, numberB := strings.TrimPrefix(partsA[i], "Rule "), strings.TrimPrefix(partsB[i], "Rule ")
+		numberedA, numberedB := startsWithDigit(numberA), startsWithDigit(numberB)
+		switch {
+		case numberedA && numberedB:
+			return citation.Less(numberA, numberB)
+		case numberedA != numberedB:
+			return numberedA
+		default:
+			return partsA[i] < partsB[i]
+		}
+	}
+	return len(partsA) < len(partsB)
+}
+
+// startsWithDigit reports whether s starts with a digit
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// part is the combined text of the chunks stored under one hierarchy path
+type part struct {
+	title string
+	text  string
+}
+
+// groupByKey joins the content of chunks sharing a hierarchy path, falling
+// back to the subsection number for chunks without one
+func groupByKey(chunks []models.TextChunk) map[string]part {
+	sorted := make([]models.TextChunk, len(chunks))
+	copy(sorted, chunks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	parts := make(map[string]part)
+	for _, chunk := range sorted {
+		key := chunk.Metadata.Hierarchy
+		if key == "" {
+			key = chunk.Metadata.Subsection
+		}
+		if key == "" {
+			continue
+		}
+
+		p := parts[key]
+		if p.title == "" {
+			p.title = chunk.Metadata.SubsecTitle
+			if p.title == "" {
+				p.title = chunk.Metadata.Title
+			}
+		}
+		if p.text != "" {
+			p.text += "\n\n"
+		}
+		p.text += strings.TrimSpace(chunk.Content)
+		parts[key] = p
+	}
+
+	return parts
+}
+
+// normalize collapses whitespace so that reflowed text does not count as a change
+func normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Text returns a word-level diff of two texts, marking removed words as
+// [-words-] and added words as {+words+}
+func Text(oldText, newText string) string {
+	a := strings.Fields(oldText)
+	b := strings.Fields(newText)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	var removed, added []string
+
+	// flush writes the pending removed and added runs before a common word
+	flush := func() {
+		if len(removed) > 0 {
+			writeWord(&sb, "[-"+strings.Join(removed, " ")+"-]")
+			removed = removed[:0]
+		}
+		if len(added) > 0 {
+			writeWord(&sb, "{+"+strings.Join(added, " ")+"+}")
+			added = added[:0]
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			flush()
+			writeWord(&sb, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	removed = append(removed, a[i:]...)
+	added = append(added, b[j:]...)
+	flush()
+
+	return sb.String()
+}
+
+// writeWord appends a word to the builder, separating it from the previous one
+func writeWord(sb *strings.Builder, word string) {
+	if sb.Len() > 0 {
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(word)
+}
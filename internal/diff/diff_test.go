@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"unchanged", "The ball must be dropped.", "The ball must be dropped.", "The ball must be dropped."},
+		{"replaced", "The ball must be dropped.", "The ball must be placed.", "The ball must be [-dropped.-] {+placed.+}"},
+		{"added", "Mark the ball.", "Mark the ball now.", "Mark the [-ball.-] {+ball now.+}"},
+		{"inserted", "Mark the ball.", "Mark and lift the ball.", "Mark {+and lift+} the ball."},
+		{"removed", "The player may not touch the sand.", "The player may touch the sand.",
+			"The player may [-not-] touch the sand."},
+		{"from nothing", "", "A new rule.", "{+A new rule.+}"},
+		{"to nothing", "An old rule.", "", "[-An old rule.-]"},
+		{"reflowed", "The ball\nmust be  dropped.", "The ball must be\ndropped.", "The ball must be dropped."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.old, tt.new); got != tt.want {
+				t.Errorf("Text(%q, %q) = %q, want %q", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+// chunk builds a chunk of a rule part with its hierarchy path
+func chunk(id int, path, title, content string) models.TextChunk {
+	return models.TextChunk{ID: id, Content: content, Metadata: models.Metadata{Hierarchy: path, SubsecTitle: title}}
+}
+
+func TestEditions(t *testing.T) {
+	oldChunks := []models.TextChunk{
+		chunk(1, "Rule 2 > 2.1", "Boundaries", "The course is defined by its boundaries."),
+		chunk(2, "Rule 3 > 3.1", "Central Elements", "Every competition involves a format."),
+		chunk(3, "Rule 10 > 10.1", "Making a Stroke", "A stroke is made by fairly striking at the ball."),
+		chunk(4, "Rule 14 > 14.3", "Dropping Ball", "The ball must be dropped\nfrom knee height."),
+		chunk(5, "Rule 14 > 14.3", "Dropping Ball", "It must come to rest in the relief area."),
+	}
+	newChunks := []models.TextChunk{
+		chunk(1, "Rule 2 > 2.1", "Boundaries", "The course is defined by its boundaries."),
+		chunk(2, "Rule 2 > 2.2", "Defined Areas", "There are five areas of the course."),
+		chunk(3, "Rule 10 > 10.1", "Making a Stroke", "A stroke is made by fairly striking at the ball with the head."),
+		chunk(4, "Rule 10 > 10.2", "Advice", "A player must not give advice."),
+		chunk(5, "Rule 14 > 14.3", "Dropping Ball", "The ball must be dropped from knee height."),
+		chunk(6, "Rule 14 > 14.3", "Dropping Ball", "It must come to rest  in the relief area."),
+		{ID: 7, Content: "An area of the course.", Metadata: models.Metadata{Hierarchy: "Definitions > Penalty Area",
+			Title: "Penalty Area"}},
+	}
+
+	want := []Change{
+		{Key: "Rule 2 > 2.2", Kind: Added, Title: "Defined Areas", New: "There are five areas of the course."},
+		{Key: "Rule 3 > 3.1", Kind: Removed, Title: "Central Elements", Old: "Every competition involves a format."},
+		{
+			Key:   "Rule 10 > 10.1",
+			Kind:  Modified,
+			Title: "Making a Stroke",
+			Old:   "A stroke is made by fairly striking at the ball.",
+			New:   "A stroke is made by fairly striking at the ball with the head.",
+			Diff:  "A stroke is made by fairly striking at the [-ball.-] {+ball with the head.+}",
+		},
+		{Key: "Rule 10 > 10.2", Kind: Added, Title: "Advice", New: "A player must not give advice."},
+		{Key: "Definitions > Penalty Area", Kind: Added, Title: "Penalty Area", New: "An area of the course."},
+	}
+
+	if got := Editions(oldChunks, newChunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("Editions() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPathLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Rule 2", "Rule 10", true},
+		{"Rule 10", "Rule 2", false},
+		{"Rule 14 > 14.3", "Rule 14 > 14.3 > 14.3c", true},
+		{"Rule 14 > 14.3", "Rule 14 > 14.10", true},
+		{"Rule 14 > 14.3 > 14.3c > 14.3c(1)", "Rule 14 > 14.3 > 14.3c > Exception 1", true},
+		{"Rule 24", "Definitions > Ball", true},
+		{"Definitions > Ball", "Definitions > Bunker", true},
+		{"Rule 2", "Rule 2", false},
+	}
+
+	for _, tt := range tests {
+		if got := pathLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("pathLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
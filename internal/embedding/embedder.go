@@ -0,0 +1,123 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// Supported embedding providers
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Embedder creates vector embeddings for text
+type Embedder interface {
+	// EmbedText generates an embedding for a text
+	EmbedText(ctx context.Context, text string) ([]float64, error)
+
+	// EmbedBatch generates embeddings for multiple chunks in parallel
+	EmbedBatch(ctx context.Context, chunks []models.TextChunk) ([]models.TextChunk, error)
+
+	// EmbedBatchWithProgress generates embeddings with progress reporting
+	EmbedBatchWithProgress(ctx context.Context, chunks []models.TextChunk,
+		progressFunc func(processed, total int)) ([]models.TextChunk, error)
+}
+
+// Config selects and configures an embedding provider
+type Config struct {
+	Provider      string
+	OllamaHost    string // Ollama host, defaults to OLLAMA_HOST
+	BaseURL       string // Base URL of an OpenAI-compatible server
+	APIKey        string
+	Model         string
+	MaxConcurrent int // Zero keeps the provider default
+}
+
+// New creates the embedder for the configured provider
+func New(cfg Config) (Embedder, error) {
+	switch cfg.Provider {
+	case ProviderOllama, "":
+		embedder, err := NewOllamaEmbedder(cfg.OllamaHost, cfg.Model)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.MaxConcurrent > 0 {
+			embedder.MaxConcurrent = cfg.MaxConcurrent
+		}
+		return embedder, nil
+	case ProviderOpenAI:
+		embedder := NewOpenAIEmbedder(cfg.BaseURL, cfg.APIKey, cfg.Model)
+		if cfg.MaxConcurrent > 0 {
+			embedder.MaxConcurrent = cfg.MaxConcurrent
+		}
+		return embedder, nil
+	case ProviderFake:
+		return NewFakeEmbedder(FakeDimension), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", cfg.Provider)
+	}
+}
+
+// embedBatch embeds chunks in parallel with at most maxConcurrent requests in flight
+func embedBatch(ctx context.Context, embedText func(context.Context, string) ([]float64, error),
+	maxConcurrent int, chunks []models.TextChunk, progressFunc func(processed, total int)) ([]models.TextChunk, error) {
+
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrent)
+
+	// Create a mutex to protect access to the chunks slice and progress counter
+	var mu sync.Mutex
+	processed := 0
+	total := len(chunks)
+
+	// Track errors
+	errChan := make(chan error, total)
+
+	// Process chunks in parallel
+	for i := range chunks {
+		wg.Add(1)
+		semaphore <- struct{}{} // Acquire semaphore
+
+		go func(i int) {
+			defer func() {
+				wg.Done()
+				<-semaphore
+			}() // Release semaphore
+
+			// Create embedding for this chunk
+			embedding, err := embedText(ctx, chunks[i].Content)
+			if err != nil {
+				errChan <- fmt.Errorf("failed to embed chunk %d: %w", chunks[i].ID, err)
+				return
+			}
+
+			// Update the chunk with its embedding
+			mu.Lock()
+			chunks[i].Embedding = embedding
+			processed++
+			if progressFunc != nil {
+				progressFunc(processed, total)
+			}
+			mu.Unlock()
+		}(i)
+	}
+
+	// Wait for all goroutines to complete
+	wg.Wait()
+	close(errChan)
+
+	// Check for errors
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
@@ -0,0 +1,89 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// ProviderFake selects the deterministic offline embedder
+	ProviderFake = "fake"
+
+	// FakeDimension is the size of the vectors produced by FakeEmbedder
+	FakeDimension = 384
+)
+
+// FakeEmbedder produces deterministic embeddings by hashing words into a
+// fixed number of buckets. Texts sharing words get similar vectors, which is
+// enough to exercise retrieval end-to-end without a model server.
+type FakeEmbedder struct {
+	Dimension int
+}
+
+// NewFakeEmbedder creates a fake embedder producing vectors of the given dimension
+func NewFakeEmbedder(dimension int) *FakeEmbedder {
+	if dimension <= 0 {
+		dimension = FakeDimension
+	}
+	return &FakeEmbedder{Dimension: dimension}
+}
+
+// EmbedText generates a stable, L2-normalised embedding for a text
+func (e *FakeEmbedder) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vector := make([]float64, e.Dimension)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		words = []string{text}
+	}
+
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		// Low bits pick the bucket, the top bit picks the sign
+		bucket := int(sum % uint64(e.Dimension))
+		if sum>>63 == 1 {
+			vector[bucket]--
+		} else {
+			vector[bucket]++
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+
+	return vector, nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+func (e *FakeEmbedder) EmbedBatch(ctx context.Context, chunks []models.TextChunk) ([]models.TextChunk, error) {
+	return embedBatch(ctx, e.EmbedText, 1, chunks, nil)
+}
+
+// EmbedBatchWithProgress generates embeddings with progress reporting
+func (e *FakeEmbedder) EmbedBatchWithProgress(ctx context.Context, chunks []models.TextChunk,
+	progressFunc func(processed, total int)) ([]models.TextChunk, error) {
+
+	return embedBatch(ctx, e.EmbedText, 1, chunks, progressFunc)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"sync"
+	"net/url"
 	"time"
 
 	"golf-rules-rag/internal/models"
@@ -26,7 +26,11 @@ type OllamaEmbedder struct {
 func NewOllamaEmbedder(host string, model string) (*OllamaEmbedder, error) {
 	hostURL := envconfig.Host()
 	if host != "" {
-
+		parsed, err := url.Parse(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Ollama host %q: %w", host, err)
+		}
+		hostURL = parsed
 	}
 	client := api.NewClient(hostURL, http.DefaultClient)
 
@@ -83,102 +87,12 @@ func (e *OllamaEmbedder) createEmbedding(ctx context.Context, text string) ([]fl
 
 // EmbedBatch generates embeddings for multiple texts in parallel
 func (e *OllamaEmbedder) EmbedBatch(ctx context.Context, chunks []models.TextChunk) ([]models.TextChunk, error) {
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, e.MaxConcurrent)
-
-	// Create a mutex to protect access to the chunks slice
-	var mu sync.Mutex
-
-	// Track errors
-	errChan := make(chan error, len(chunks))
-
-	// Process chunks in parallel
-	for i := range chunks {
-		wg.Add(1)
-		semaphore <- struct{}{} // Acquire semaphore
-
-		go func(i int) {
-			defer wg.Done()
-			defer func() { <-semaphore }() // Release semaphore
-
-			// Create embedding for this chunk
-			embedding, err := e.EmbedText(ctx, chunks[i].Content)
-			if err != nil {
-				errChan <- fmt.Errorf("failed to embed chunk %d: %w", chunks[i].ID, err)
-				return
-			}
-
-			// Update the chunk with its embedding
-			mu.Lock()
-			chunks[i].Embedding = embedding
-			mu.Unlock()
-		}(i)
-	}
-
-	// Wait for all goroutines to complete
-	wg.Wait()
-	close(errChan)
-
-	// Check for errors
-	if err := <-errChan; err != nil {
-		return nil, err
-	}
-
-	return chunks, nil
+	return embedBatch(ctx, e.EmbedText, e.MaxConcurrent, chunks, nil)
 }
 
 // EmbedBatchWithProgress generates embeddings with progress reporting
 func (e *OllamaEmbedder) EmbedBatchWithProgress(ctx context.Context, chunks []models.TextChunk,
 	progressFunc func(processed, total int)) ([]models.TextChunk, error) {
 
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, e.MaxConcurrent)
-
-	// Create a mutex to protect access to the chunks slice and progress counter
-	var mu sync.Mutex
-	processed := 0
-	total := len(chunks)
-
-	// Track errors
-	errChan := make(chan error, total)
-
-	// Process chunks in parallel
-	for i := range chunks {
-		wg.Add(1)
-		semaphore <- struct{}{} // Acquire semaphore
-
-		go func(i int) {
-			defer func() {
-				wg.Done()
-				<-semaphore
-			}() // Release semaphore
-
-			// Create embedding for this chunk
-			embedding, err := e.EmbedText(ctx, chunks[i].Content)
-			if err != nil {
-				errChan <- fmt.Errorf("failed to embed chunk %d: %w", chunks[i].ID, err)
-				return
-			}
-
-			// Update the chunk with its embedding
-			mu.Lock()
-			chunks[i].Embedding = embedding
-			processed++
-			if progressFunc != nil {
-				progressFunc(processed, total)
-			}
-			mu.Unlock()
-		}(i)
-	}
-
-	// Wait for all goroutines to complete
-	wg.Wait()
-	close(errChan)
-
-	// Check for errors
-	if err := <-errChan; err != nil {
-		return nil, err
-	}
-
-	return chunks, nil
+	return embedBatch(ctx, e.EmbedText, e.MaxConcurrent, chunks, progressFunc)
 }
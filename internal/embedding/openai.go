@@ -0,0 +1,77 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/openai"
+)
+
+// OpenAIEmbedder generates embeddings using an OpenAI-compatible /v1/embeddings API
+type OpenAIEmbedder struct {
+	Client        *openai.Client
+	Model         string
+	MaxRetries    int
+	Timeout       time.Duration
+	MaxConcurrent int
+}
+
+// NewOpenAIEmbedder creates a new embedder for the OpenAI-compatible server at baseURL
+func NewOpenAIEmbedder(baseURL, apiKey, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		Client:        openai.NewClient(baseURL, apiKey),
+		Model:         model,
+		MaxRetries:    3,
+		Timeout:       time.Second * 30,
+		MaxConcurrent: 3,
+	}
+}
+
+// EmbedText generates an embedding for a text
+func (e *OpenAIEmbedder) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	var embedding []float64
+	var err error
+
+	for retries := 0; retries <= e.MaxRetries; retries++ {
+		if retries > 0 {
+			time.Sleep(time.Duration(retries) * time.Second)
+		}
+
+		embedding, err = e.createEmbedding(ctx, text)
+		if err == nil {
+			return embedding, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to create embedding after %d retries: %w", e.MaxRetries, err)
+}
+
+// createEmbedding is a helper function to create a single embedding
+func (e *OpenAIEmbedder) createEmbedding(ctx context.Context, text string) ([]float64, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	resp, err := e.Client.Embeddings(ctxWithTimeout, &openai.EmbeddingRequest{
+		Model: e.Model,
+		Input: text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+
+	return resp.Data[0].Embedding, nil
+}
+
+// EmbedBatch generates embeddings for multiple texts in parallel
+func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, chunks []models.TextChunk) ([]models.TextChunk, error) {
+	return embedBatch(ctx, e.EmbedText, e.MaxConcurrent, chunks, nil)
+}
+
+// EmbedBatchWithProgress generates embeddings with progress reporting
+func (e *OpenAIEmbedder) EmbedBatchWithProgress(ctx context.Context, chunks []models.TextChunk,
+	progressFunc func(processed, total int)) ([]models.TextChunk, error) {
+
+	return embedBatch(ctx, e.EmbedText, e.MaxConcurrent, chunks, progressFunc)
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+)
+
+// DefaultMaxTurns is how many turns a conversation remembers by default
+const DefaultMaxTurns = 5
+
+// Conversation is the bounded history of one question answering session.
+// Only the most recent MaxTurns turns are kept.
+type Conversation struct {
+	MaxTurns int
+	turns    []models.ConversationTurn
+}
+
+// NewConversation creates an empty conversation remembering up to maxTurns turns
+func NewConversation(maxTurns int) *Conversation {
+	return &Conversation{MaxTurns: maxTurns}
+}
+
+// Add records a turn, dropping the oldest one when the history is full
+func (c *Conversation) Add(question, answer string) {
+	if c.MaxTurns <= 0 {
+		return
+	}
+	c.turns = append(c.turns, models.ConversationTurn{Question: question, Answer: answer})
+	c.turns = TrimHistory(c.turns, c.MaxTurns)
+}
+
+// Turns returns the remembered turns, oldest first
+func (c *Conversation) Turns() []models.ConversationTurn {
+	return c.turns
+}
+
+// Reset forgets every turn
+func (c *Conversation) Reset() {
+	c.turns = nil
+}
+
+// TrimHistory returns the last maxTurns turns of history
+func TrimHistory(history []models.ConversationTurn, maxTurns int) []models.ConversationTurn {
+	if maxTurns <= 0 {
+		return nil
+	}
+	if len(history) > maxTurns {
+		history = history[len(history)-maxTurns:]
+	}
+	return history
+}
+
+// RewriteQuery asks the generator to turn a follow-up question into a
+// standalone one, so that retrieval does not depend on earlier turns. The
+// question is returned unchanged when there is no history.
+func RewriteQuery(ctx context.Context, g Generator, history []models.ConversationTurn, question string) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+
+	rewritten, err := g.GenerateResponse(ctx, GenerateRewritePrompt(history, question))
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite query: %w", err)
+	}
+
+	// Models sometimes echo the label or quote the question
+	rewritten = strings.TrimSpace(rewritten)
+	rewritten = strings.TrimPrefix(rewritten, "Standalone question:")
+	rewritten = strings.Trim(strings.TrimSpace(rewritten), `"`)
+	if rewritten == "" {
+		return question, nil
+	}
+	return rewritten, nil
+}
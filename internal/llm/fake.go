@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golf-rules-rag/internal/models"
+)
+
+// ProviderFake selects the scripted offline LLM
+const ProviderFake = "fake"
+
+// FakeLLM is a scripted Generator for offline tests. Its answers echo the
+// IDs and hierarchy paths of the contexts it was given, so tests can assert
+// on what retrieval passed to the model.
+type FakeLLM struct {
+	// Script, when set, produces the completion for a raw prompt other than
+	// a query rewriting prompt
+	Script func(prompt string) string
+}
+
+// NewFakeLLM creates a fake LLM with no script
+func NewFakeLLM() *FakeLLM {
+	return &FakeLLM{}
+}
+
+// GenerateResponse returns the scripted completion for the prompt. Query
+// rewriting prompts are always answered with a standalone question, so that
+// follow-ups retrieve contexts offline.
+func (f *FakeLLM) GenerateResponse(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if question, ok := fakeRewrite(prompt); ok {
+		return question, nil
+	}
+	if f.Script != nil {
+		return f.Script(prompt), nil
+	}
+	return fmt.Sprintf("Fake response to a %d character prompt.", len(prompt)), nil
+}
+
+// GenerateStream streams the scripted completion for the prompt word by word
+func (f *FakeLLM) GenerateStream(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
+	text, err := f.GenerateResponse(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	return text, streamWords(ctx, text, onToken)
+}
+
+// Answer returns an answer listing the contexts in the order they were given.
+// The answer options are ignored.
+func (f *FakeLLM) Answer(ctx context.Context, query string, contexts []models.TextChunk,
+	opts AnswerOptions) (*models.Response, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.Response{
+		Answer:    FakeAnswer(query, contexts),
+		Sources:   contexts,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// AnswerStream returns the same answer as Answer, streaming it word by word
+func (f *FakeLLM) AnswerStream(ctx context.Context, query string, contexts []models.TextChunk,
+	opts AnswerOptions, onToken TokenFunc) (*models.Response, error) {
+
+	response, err := f.Answer(ctx, query, contexts, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := streamWords(ctx, response.Answer, onToken); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// streamWords passes text to onToken one word at a time, each with its
+// trailing whitespace
+func streamWords(ctx context.Context, text string, onToken TokenFunc) error {
+	if onToken == nil {
+		return nil
+	}
+	for _, word := range strings.SplitAfter(text, " ") {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if word == "" {
+			continue
+		}
+		if err := onToken(word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeRewrite answers a query rewriting prompt by appending the follow-up
+// question to the previous one, which keeps the topic for retrieval
+func fakeRewrite(prompt string) (string, bool) {
+	if !strings.HasPrefix(prompt, rewritePromptPrefix) {
+		return "", false
+	}
+
+	var previous, followUp string
+	for _, line := range strings.Split(prompt, "\n") {
+		if question, ok := strings.CutPrefix(line, "Question: "); ok {
+			previous = question
+		}
+		if question, ok := strings.CutPrefix(line, "Follow-up question: "); ok {
+			followUp = question
+		}
+	}
+	return strings.TrimSpace(previous + " " + followUp), true
+}
+
+// FakeAnswer is the deterministic answer FakeLLM gives for a query and its contexts
+func FakeAnswer(query string, contexts []models.TextChunk) string {
+	var sb strings.Builder
+
+	sb.WriteString("Fake answer to: " + query + "\n")
+	sb.WriteString("Contexts:")
+	for _, c := range contexts {
+		path := c.Metadata.Hierarchy
+		if path == "" {
+			path = c.Metadata.Section
+		}
+		sb.WriteString(fmt.Sprintf(" [%d] %s;", c.ID, path))
+	}
+
+	return strings.TrimSuffix(sb.String(), ";")
+}
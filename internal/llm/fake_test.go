@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestFakeLLMRewriteQuery(t *testing.T) {
+	ctx := context.Background()
+	history := []models.ConversationTurn{
+		{Question: "Can I move a loose impediment in a bunker?", Answer: "Fake answer to: ...\nContexts: [3] Rule 12 > 12.2"},
+	}
+
+	tests := []struct {
+		name     string
+		fake     *FakeLLM
+		history  []models.ConversationTurn
+		question string
+		want     string
+	}{
+		{
+			name:     "no history",
+			fake:     NewFakeLLM(),
+			question: "What about a penalty area?",
+			want:     "What about a penalty area?",
+		},
+		{
+			name:     "follow-up keeps the topic",
+			fake:     NewFakeLLM(),
+			history:  history,
+			question: "What about a penalty area?",
+			want:     "Can I move a loose impediment in a bunker? What about a penalty area?",
+		},
+		{
+			name:     "script does not answer rewrites",
+			fake:     &FakeLLM{Script: func(string) string { return "scripted" }},
+			history:  history,
+			question: "What about a penalty area?",
+			want:     "Can I move a loose impediment in a bunker? What about a penalty area?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RewriteQuery(ctx, tt.fake, tt.history, tt.question)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RewriteQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeLLMGenerateResponse(t *testing.T) {
+	ctx := context.Background()
+
+	got, err := NewFakeLLM().GenerateResponse(ctx, "Rate this context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Fake response to a 17 character prompt."; got != want {
+		t.Errorf("GenerateResponse() = %q, want %q", got, want)
+	}
+
+	scripted := &FakeLLM{Script: func(prompt string) string { return "7" }}
+	if got, _ := scripted.GenerateResponse(ctx, "Rate this context"); got != "7" {
+		t.Errorf("scripted GenerateResponse() = %q, want %q", got, "7")
+	}
+
+	var streamed string
+	text, err := scripted.GenerateStream(ctx, "Rate this context", func(token string) error {
+		streamed += token
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "7" || streamed != "7" {
+		t.Errorf("GenerateStream() = %q, streamed %q, want %q", text, streamed, "7")
+	}
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// Supported LLM providers
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Generator produces answers from a language model
+type Generator interface {
+	// GenerateResponse generates a completion for a raw prompt
+	GenerateResponse(ctx context.Context, prompt string) (string, error)
+
+	// GenerateStream generates a completion for a raw prompt, calling onToken
+	// with each piece of text as it arrives, and returns the whole completion
+	GenerateStream(ctx context.Context, prompt string, onToken TokenFunc) (string, error)
+
+	// Answer answers a query using the retrieved contexts
+	Answer(ctx context.Context, query string, contexts []models.TextChunk,
+		opts AnswerOptions) (*models.Response, error)
+
+	// AnswerStream answers a query like Answer, streaming the answer to onToken
+	AnswerStream(ctx context.Context, query string, contexts []models.TextChunk,
+		opts AnswerOptions, onToken TokenFunc) (*models.Response, error)
+}
+
+// TokenFunc receives streamed pieces of generated text. Returning an error
+// stops generation.
+type TokenFunc func(token string) error
+
+// Config selects and configures an LLM provider
+type Config struct {
+	Provider   string
+	OllamaHost string // Ollama host, defaults to OLLAMA_HOST
+	BaseURL    string // Base URL of an OpenAI-compatible server
+	APIKey     string
+	Model      string
+}
+
+// New creates the generator for the configured provider
+func New(cfg Config) (Generator, error) {
+	switch cfg.Provider {
+	case ProviderOllama, "":
+		return NewOllamaLLM(cfg.OllamaHost, cfg.Model)
+	case ProviderOpenAI:
+		return NewOpenAILLM(cfg.BaseURL, cfg.APIKey, cfg.Model), nil
+	case ProviderFake:
+		return NewFakeLLM(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+// answer builds the prompt for a query, generates a response and wraps it
+// with its sources. The response is streamed to onToken when it is not nil.
+func answer(ctx context.Context, g Generator, query string, contexts []models.TextChunk,
+	opts AnswerOptions, onToken TokenFunc) (*models.Response, error) {
+
+	prompt := GeneratePrompt(query, contexts, opts)
+
+	var text string
+	var err error
+	if onToken != nil {
+		text, err = g.GenerateStream(ctx, prompt, onToken)
+	} else {
+		text, err = g.GenerateResponse(ctx, prompt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	timestamp := time.Now().Format(time.RFC3339)
+
+	return &models.Response{
+		Answer:    text,
+		Sources:   contexts,
+		Timestamp: timestamp,
+	}, nil
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
-	"time"
 
 	"golf-rules-rag/internal/models"
 
@@ -23,7 +23,11 @@ type OllamaLLM struct {
 func NewOllamaLLM(host string, model string) (*OllamaLLM, error) {
 	hostURL := envconfig.Host()
 	if host != "" {
-
+		parsed, err := url.Parse(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Ollama host %q: %w", host, err)
+		}
+		hostURL = parsed
 	}
 	client := api.NewClient(hostURL, http.DefaultClient)
 
@@ -33,62 +37,14 @@ func NewOllamaLLM(host string, model string) (*OllamaLLM, error) {
 	}, nil
 }
 
-// GeneratePrompt creates a prompt for the LLM with enhanced structural context
-func (o *OllamaLLM) GeneratePrompt(query string, contexts []models.TextChunk) string {
-	var promptBuilder strings.Builder
-
-	// Enhanced system instruction
-	promptBuilder.WriteString("You are GolfRulesGPT, an expert on the Official Rules of Golf. ")
-	promptBuilder.WriteString("Answer questions about golf rules accurately based on the provided context. ")
-	promptBuilder.WriteString("When referencing rules, use the exact rule numbers and include complete hierarchical references (e.g., Rule 11.2b(1)). ")
-	promptBuilder.WriteString("If you need to reference a definition, use its proper name from the Rules of Golf. ")
-	promptBuilder.WriteString("If the answer is not in the context, say 'I don't have enough information to answer that question based on the official golf rules.'\n\n")
-
-	// Add context with full hierarchical information
-	promptBuilder.WriteString("Context from the Official Rules of Golf:\n")
-	for i, ctx := range contexts {
-		// Include detailed hierarchical information
-		hierarchyPath := ctx.Metadata.Hierarchy
-		chunkType := ctx.Metadata.ChunkType
-
-		contextHeader := fmt.Sprintf("Context %d [Type: %s, Path: %s", i+1, chunkType, hierarchyPath)
-		if ctx.Metadata.Subsection != "" {
-			if ctx.Metadata.SubsecTitle != "" {
-				contextHeader += fmt.Sprintf(", Subsection: %s - %s",
-					ctx.Metadata.Subsection, ctx.Metadata.SubsecTitle)
-			} else {
-				contextHeader += fmt.Sprintf(", Subsection: %s", ctx.Metadata.Subsection)
-			}
-		}
-		contextHeader += fmt.Sprintf(", Page: %d]:\n", ctx.Metadata.PageNumber)
-
-		promptBuilder.WriteString(contextHeader)
-		promptBuilder.WriteString(ctx.Content)
-
-		// Add cross-references if available
-		if len(ctx.CrossReferences) > 0 {
-			promptBuilder.WriteString("\nCross References: ")
-			promptBuilder.WriteString(strings.Join(ctx.CrossReferences, ", "))
-		}
-
-		// Add index terms if available
-		if len(ctx.IndexTerms) > 0 {
-			promptBuilder.WriteString("\nKey Terms: ")
-			promptBuilder.WriteString(strings.Join(ctx.IndexTerms, ", "))
-		}
-
-		promptBuilder.WriteString("\n\n")
-	}
-
-	// Add query
-	promptBuilder.WriteString("Question: " + query + "\n\n")
-	promptBuilder.WriteString("Answer: ")
-
-	return promptBuilder.String()
-}
-
 // GenerateResponse generates a response from the LLM
 func (o *OllamaLLM) GenerateResponse(ctx context.Context, prompt string) (string, error) {
+	return o.GenerateStream(ctx, prompt, nil)
+}
+
+// GenerateStream generates a response from the LLM, passing each streamed
+// piece to onToken when it is not nil
+func (o *OllamaLLM) GenerateStream(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
 	req := api.GenerateRequest{
 		Model:  o.Model,
 		Prompt: prompt,
@@ -101,8 +57,11 @@ func (o *OllamaLLM) GenerateResponse(ctx context.Context, prompt string) (string
 	var responseBuilder strings.Builder
 
 	err := o.Client.Generate(ctx, &req, func(resp api.GenerateResponse) error {
-		_, err := responseBuilder.WriteString(resp.Response)
-		return err
+		responseBuilder.WriteString(resp.Response)
+		if onToken != nil && resp.Response != "" {
+			return onToken(resp.Response)
+		}
+		return nil
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate response: %w", err)
@@ -112,19 +71,15 @@ func (o *OllamaLLM) GenerateResponse(ctx context.Context, prompt string) (string
 }
 
 // Answer answers a query using the LLM and context
-func (o *OllamaLLM) Answer(ctx context.Context, query string, contexts []models.TextChunk) (*models.Response, error) {
-	prompt := o.GeneratePrompt(query, contexts)
+func (o *OllamaLLM) Answer(ctx context.Context, query string, contexts []models.TextChunk,
+	opts AnswerOptions) (*models.Response, error) {
 
-	answer, err := o.GenerateResponse(ctx, prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate response: %w", err)
-	}
+	return answer(ctx, o, query, contexts, opts, nil)
+}
 
-	timestamp := time.Now().Format(time.RFC3339)
+// AnswerStream answers a query using the LLM and context, streaming the answer
+func (o *OllamaLLM) AnswerStream(ctx context.Context, query string, contexts []models.TextChunk,
+	opts AnswerOptions, onToken TokenFunc) (*models.Response, error) {
 
-	return &models.Response{
-		Answer:    answer,
-		Sources:   contexts,
-		Timestamp: timestamp,
-	}, nil
+	return answer(ctx, o, query, contexts, opts, onToken)
 }
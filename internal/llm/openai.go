@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/openai"
+)
+
+// OpenAILLM handles interactions with an OpenAI-compatible /v1/chat/completions API
+type OpenAILLM struct {
+	Client *openai.Client
+	Model  string
+}
+
+// NewOpenAILLM creates a new client for the OpenAI-compatible server at baseURL
+func NewOpenAILLM(baseURL, apiKey, model string) *OpenAILLM {
+	return &OpenAILLM{
+		Client: openai.NewClient(baseURL, apiKey),
+		Model:  model,
+	}
+}
+
+// GenerateResponse generates a response from the LLM
+func (o *OpenAILLM) GenerateResponse(ctx context.Context, prompt string) (string, error) {
+	resp, err := o.Client.ChatCompletion(ctx, &openai.ChatRequest{
+		Model: o.Model,
+		Messages: []openai.ChatMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1,
+		MaxTokens:   1024,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+// GenerateStream generates a response from the LLM, passing each streamed
+// piece to onToken
+func (o *OpenAILLM) GenerateStream(ctx context.Context, prompt string, onToken TokenFunc) (string, error) {
+	var responseBuilder strings.Builder
+
+	err := o.Client.ChatCompletionStream(ctx, &openai.ChatRequest{
+		Model: o.Model,
+		Messages: []openai.ChatMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1,
+		MaxTokens:   1024,
+	}, func(delta string) error {
+		responseBuilder.WriteString(delta)
+		if onToken != nil {
+			return onToken(delta)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	return responseBuilder.String(), nil
+}
+
+// Answer answers a query using the LLM and context
+func (o *OpenAILLM) Answer(ctx context.Context, query string, contexts []models.TextChunk,
+	opts AnswerOptions) (*models.Response, error) {
+
+	return answer(ctx, o, query, contexts, opts, nil)
+}
+
+// AnswerStream answers a query using the LLM and context, streaming the answer
+func (o *OpenAILLM) AnswerStream(ctx context.Context, query string, contexts []models.TextChunk,
+	opts AnswerOptions, onToken TokenFunc) (*models.Response, error) {
+
+	return answer(ctx, o, query, contexts, opts, onToken)
+}
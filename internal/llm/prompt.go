@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+)
+
+// AnswerOptions carries what an answer depends on besides the query and contexts
+type AnswerOptions struct {
+	History     []models.ConversationTurn // Earlier turns of the conversation, oldest first
+	PlayFormat  string                    // models.PlayFormatMatch or models.PlayFormatStroke; empty for both
+	Definitions []models.TextChunk        // Definitions of the terms the query and contexts use
+}
+
+// GeneratePrompt creates a prompt for the LLM with enhanced structural context.
+// Earlier turns of the conversation, if any, are included before the question
+// so that follow-up questions can be answered in context, and a chosen play
+// format is stated so that the answer gives the penalty for that format.
+// Definitions of the terms used follow the contexts in a block of their own.
+func GeneratePrompt(query string, contexts []models.TextChunk, opts AnswerOptions) string {
+	var promptBuilder strings.Builder
+
+	// Enhanced system instruction
+	promptBuilder.WriteString("You are GolfRulesGPT, an expert on the Official Rules of Golf. ")
+	promptBuilder.WriteString("Answer questions about golf rules accurately based on the provided context. ")
+	promptBuilder.WriteString("When referencing rules, use the exact rule numbers and include complete hierarchical references (e.g., Rule 11.2b(1)). ")
+	promptBuilder.WriteString("If you need to reference a definition, use its proper name from the Rules of Golf. ")
+	promptBuilder.WriteString("If the answer is not in the context, say 'I don't have enough information to answer that question based on the official golf rules.'\n\n")
+
+	// Add context with full hierarchical information
+	promptBuilder.WriteString("Context from the Official Rules of Golf:\n")
+	for i, ctx := range contexts {
+		// Include detailed hierarchical information
+		hierarchyPath := ctx.Metadata.Hierarchy
+		chunkType := ctx.Metadata.ChunkType
+
+		contextHeader := fmt.Sprintf("Context %d [Type: %s, Path: %s", i+1, chunkType, hierarchyPath)
+		if ctx.Metadata.Subsection != "" {
+			if ctx.Metadata.SubsecTitle != "" {
+				contextHeader += fmt.Sprintf(", Subsection: %s - %s",
+					ctx.Metadata.Subsection, ctx.Metadata.SubsecTitle)
+			} else {
+				contextHeader += fmt.Sprintf(", Subsection: %s", ctx.Metadata.Subsection)
+			}
+		}
+		if ctx.Metadata.PlayFormat != "" {
+			contextHeader += fmt.Sprintf(", Applies to: %s play", ctx.Metadata.PlayFormat)
+		}
+		if ctx.ReferenceScore != 0 {
+			contextHeader += ", Added by cross-reference"
+		}
+		contextHeader += fmt.Sprintf(", Page: %s]:\n", ctx.Metadata.Pages())
+
+		promptBuilder.WriteString(contextHeader)
+		promptBuilder.WriteString(ctx.Content)
+
+		// Add cross-references if available
+		if len(ctx.CrossReferences) > 0 {
+			promptBuilder.WriteString("\nCross References: ")
+			promptBuilder.WriteString(strings.Join(ctx.CrossReferences, ", "))
+		}
+
+		// Add index terms if available
+		if len(ctx.IndexTerms) > 0 {
+			promptBuilder.WriteString("\nKey Terms: ")
+			promptBuilder.WriteString(strings.Join(ctx.IndexTerms, ", "))
+		}
+
+		// Add the penalties stated in the context, so they need not be inferred
+		if len(ctx.Penalties) > 0 {
+			var penalties []string
+			for _, penalty := range ctx.Penalties {
+				penalties = append(penalties, penalty.String())
+			}
+			promptBuilder.WriteString("\nPenalties: ")
+			promptBuilder.WriteString(strings.Join(penalties, "; "))
+		}
+
+		promptBuilder.WriteString("\n\n")
+	}
+
+	// Add the definitions of the terms used
+	if len(opts.Definitions) > 0 {
+		promptBuilder.WriteString("Definitions from the Rules of Golf:\n")
+		for _, definition := range opts.Definitions {
+			promptBuilder.WriteString(definition.Metadata.Title + ": " + definitionText(definition) + "\n")
+		}
+		promptBuilder.WriteString("\n")
+	}
+
+	// Add the conversation so far
+	if len(opts.History) > 0 {
+		promptBuilder.WriteString("Conversation so far:\n")
+		writeHistory(&promptBuilder, opts.History)
+		promptBuilder.WriteString("\n")
+	}
+
+	// State the play format, since penalties differ between them
+	switch opts.PlayFormat {
+	case models.PlayFormatMatch:
+		promptBuilder.WriteString("The question is about match play. Give the match play outcome and penalty ")
+		promptBuilder.WriteString("(e.g., loss of hole for the general penalty), not the stroke play one.\n\n")
+	case models.PlayFormatStroke:
+		promptBuilder.WriteString("The question is about stroke play. Give the stroke play outcome and penalty ")
+		promptBuilder.WriteString("(e.g., two penalty strokes for the general penalty), not the match play one.\n\n")
+	}
+
+	// Add query
+	promptBuilder.WriteString("Question: " + query + "\n\n")
+	promptBuilder.WriteString("Answer: ")
+
+	return promptBuilder.String()
+}
+
+// definitionText returns the text of a definition without the term heading it
+func definitionText(definition models.TextChunk) string {
+	text := strings.TrimSpace(definition.Content)
+	if first, rest, ok := strings.Cut(text, "\n"); ok && strings.EqualFold(strings.TrimSpace(first), definition.Metadata.Title) {
+		text = rest
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// GenerateChangePrompt creates a prompt asking the LLM to summarise the
+// practical impact of a change to a rule between two editions. Either text
+// is empty when the rule was added or removed.
+func GenerateChangePrompt(path, oldEdition, newEdition, oldText, newText string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString("You are GolfRulesGPT, an expert on the Official Rules of Golf. ")
+	promptBuilder.WriteString("Compare two editions of the same part of the Rules of Golf and explain, in two or three sentences, ")
+	promptBuilder.WriteString("what the change means in practice for players and referees. ")
+	promptBuilder.WriteString("Ignore differences in wording that do not change the meaning.\n\n")
+
+	promptBuilder.WriteString(fmt.Sprintf("Part: %s\n\n", path))
+
+	promptBuilder.WriteString(fmt.Sprintf("%s edition:\n", oldEdition))
+	if oldText == "" {
+		promptBuilder.WriteString("(not present)")
+	} else {
+		promptBuilder.WriteString(oldText)
+	}
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString(fmt.Sprintf("%s edition:\n", newEdition))
+	if newText == "" {
+		promptBuilder.WriteString("(not present)")
+	} else {
+		promptBuilder.WriteString(newText)
+	}
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString("Practical impact: ")
+
+	return promptBuilder.String()
+}
+
+// GenerateRelevancePrompt creates a prompt asking the LLM to rate how well a
+// context answers a query on a scale from 0 to 10
+func GenerateRelevancePrompt(query string, context models.TextChunk) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString("You are GolfRulesGPT, an expert on the Official Rules of Golf. ")
+	promptBuilder.WriteString("Rate how useful the passage below is for answering the question, ")
+	promptBuilder.WriteString("from 0 (irrelevant) to 10 (answers it directly). ")
+	promptBuilder.WriteString("Reply with the number only.\n\n")
+
+	promptBuilder.WriteString("Question: " + query + "\n\n")
+
+	promptBuilder.WriteString(fmt.Sprintf("Passage [Path: %s]:\n", context.Metadata.Hierarchy))
+	promptBuilder.WriteString(context.Content)
+	promptBuilder.WriteString("\n\n")
+
+	promptBuilder.WriteString("Rating: ")
+
+	return promptBuilder.String()
+}
+
+// rewritePromptPrefix starts every query rewriting prompt
+const rewritePromptPrefix = "You are GolfRulesGPT, rewriting a follow-up question about the Rules of Golf. "
+
+// GenerateRewritePrompt creates a prompt asking the LLM to rewrite a follow-up
+// question into a standalone question that can be searched without the
+// conversation before it
+func GenerateRewritePrompt(history []models.ConversationTurn, question string) string {
+	var promptBuilder strings.Builder
+
+	promptBuilder.WriteString(rewritePromptPrefix)
+	promptBuilder.WriteString("Using the conversation below, rewrite the follow-up question so that it makes sense on its own, ")
+	promptBuilder.WriteString("replacing pronouns and references such as 'that rule' or 'what if' with what they refer to. ")
+	promptBuilder.WriteString("If it is already standalone, repeat it unchanged. ")
+	promptBuilder.WriteString("Reply with the rewritten question only.\n\n")
+
+	promptBuilder.WriteString("Conversation:\n")
+	writeHistory(&promptBuilder, history)
+	promptBuilder.WriteString("\n")
+
+	promptBuilder.WriteString("Follow-up question: " + question + "\n\n")
+	promptBuilder.WriteString("Standalone question: ")
+
+	return promptBuilder.String()
+}
+
+// writeHistory writes the turns of a conversation as question and answer pairs
+func writeHistory(promptBuilder *strings.Builder, history []models.ConversationTurn) {
+	for _, turn := range history {
+		promptBuilder.WriteString("Question: " + turn.Question + "\n")
+		promptBuilder.WriteString("Answer: " + strings.TrimSpace(turn.Answer) + "\n")
+	}
+}
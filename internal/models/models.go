@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 // TextChunk represents a chunk of text from the PDF
 type TextChunk struct {
 	ID              int       `json:"id"`
@@ -8,18 +13,97 @@ type TextChunk struct {
 	Embedding       []float64 `json:"embedding"`
 	CrossReferences []string  `json:"cross_references,omitempty"`
 	IndexTerms      []string  `json:"index_terms,omitempty"`
+	Penalties       []Penalty `json:"penalties,omitempty"`       // Penalty statements in the content
+	DocumentID      string    `json:"document_id,omitempty"`     // Source document the chunk was extracted from
+	ContentHash     string    `json:"content_hash,omitempty"`    // SHA-256 of Content, used for incremental indexing
+	RerankScore     float64   `json:"rerank_score,omitempty"`    // Relevance to the query assigned by reranking
+	ReferenceScore  float64   `json:"reference_score,omitempty"` // Set on chunks added by following cross-references
+	Clarifies       string    `json:"clarifies,omitempty"`       // Rule number of the context an added interpretation or Model Local Rule follows
 }
 
 // Metadata contains information about the text chunk
 type Metadata struct {
-	PageNumber  int    `json:"page_number"`
+	PageNumber  int    `json:"page_number"`            // Page the chunk starts on
+	EndPage     int    `json:"end_page,omitempty"`     // Page the chunk ends on, when it spans pages
 	Section     string `json:"section"`                // Rule number (e.g., "Rule 13")
 	Title       string `json:"title"`                  // Rule title (e.g., "Putting Greens")
-	Hierarchy   string `json:"hierarchy"`              // Complete path (e.g., "Rule 13 > 13.1 > 13.1c")
-	Subsection  string `json:"subsection,omitempty"`   // Subsection number (e.g., "13.1c")
+	Hierarchy   string `json:"hierarchy"`              // Complete path (e.g., "Rule 14 > 14.3 > 14.3c > 14.3c(1)")
+	Subsection  string `json:"subsection,omitempty"`   // Number of the part of the rule (e.g., "14.3c(1)")
 	SubsecTitle string `json:"subsec_title,omitempty"` // Subsection title
 	ChunkType   string `json:"chunk_type,omitempty"`   // "rule", "definition", "index", etc.
 	ParentRule  string `json:"parent_rule,omitempty"`  // For subsections
+	PlayFormat  string `json:"play_format,omitempty"`  // PlayFormatMatch or PlayFormatStroke; empty when it applies to both
+}
+
+// Pages describes the pages a chunk is on, e.g. "12" or "12-13"
+func (m Metadata) Pages() string {
+	if m.EndPage > m.PageNumber {
+		return fmt.Sprintf("%d-%d", m.PageNumber, m.EndPage)
+	}
+	return fmt.Sprintf("%d", m.PageNumber)
+}
+
+// ChunkTypeDefinition is the chunk type of the definitions of terms
+const ChunkTypeDefinition = "definition"
+
+// Chunk types of the Official Guide to the Rules of Golf. Interpretations
+// carry the number of the rule they interpret; Model Local Rules, such as
+// E-5, are named in their Section.
+const (
+	ChunkTypeInterpretation = "interpretation"
+	ChunkTypeModelLocalRule = "model_local_rule"
+)
+
+// Chunk types of the exceptions and notes of a part of a rule. Their chunks
+// carry the number of the part they belong to.
+const (
+	ChunkTypeException = "exception"
+	ChunkTypeNote      = "note"
+)
+
+// Play formats a rule can be specific to
+const (
+	PlayFormatMatch  = "match"
+	PlayFormatStroke = "stroke"
+)
+
+// Kinds of penalty stated by the rules
+const (
+	PenaltyGeneral           = "general"             // Loss of hole in match play, two strokes in stroke play
+	PenaltyOneStroke         = "one-stroke"          // One penalty stroke
+	PenaltyTwoStroke         = "two-stroke"          // Two penalty strokes
+	PenaltyStrokeAndDistance = "stroke-and-distance" // One penalty stroke and replaying from the previous spot
+	PenaltyLossOfHole        = "loss-of-hole"        // Loss of hole in match play
+	PenaltyDisqualification  = "disqualification"    // Disqualification
+)
+
+// Penalty is a penalty statement found in a chunk
+type Penalty struct {
+	Kind string `json:"kind"`           // One of the Penalty* kinds
+	Rule string `json:"rule,omitempty"` // Rule the penalty is for (e.g., "Rule 14.3")
+	Text string `json:"text"`           // Statement as written in the rules
+}
+
+// penaltyLabels describe the kinds of penalty in plain words
+var penaltyLabels = map[string]string{
+	PenaltyGeneral:           "General penalty",
+	PenaltyOneStroke:         "One penalty stroke",
+	PenaltyTwoStroke:         "Two penalty strokes",
+	PenaltyStrokeAndDistance: "Stroke-and-distance penalty",
+	PenaltyLossOfHole:        "Loss of hole",
+	PenaltyDisqualification:  "Disqualification",
+}
+
+// String describes the penalty and the rule it is for, e.g. "General penalty (Rule 14.3)"
+func (p Penalty) String() string {
+	label, ok := penaltyLabels[p.Kind]
+	if !ok {
+		label = p.Kind
+	}
+	if p.Rule == "" {
+		return label
+	}
+	return fmt.Sprintf("%s (%s)", label, p.Rule)
 }
 
 // GolfRuleHierarchy represents the hierarchical structure of golf rules
@@ -27,6 +111,7 @@ type GolfRuleHierarchy struct {
 	RuleNumber string                 `json:"rule_number"`
 	Title      string                 `json:"title"`
 	PageNumber int                    `json:"page_number"`
+	EndPage    int                    `json:"end_page,omitempty"`
 	Sections   map[string]RuleSection `json:"sections"`
 	Path       string                 `json:"path"`
 	IndexTerms []string               `json:"index_terms,omitempty"`
@@ -37,20 +122,26 @@ type RuleSection struct {
 	Number          string                    `json:"number"`
 	Title           string                    `json:"title"`
 	PageNumber      int                       `json:"page_number"`
+	EndPage         int                       `json:"end_page,omitempty"`
 	Subsections     map[string]RuleSubsection `json:"subsections,omitempty"`
 	Content         string                    `json:"content,omitempty"`
 	Path            string                    `json:"path"`
 	CrossReferences []string                  `json:"cross_references,omitempty"`
 }
 
-// RuleSubsection represents a subsection within a section
+// RuleSubsection represents a subsection within a section, or a part of a
+// subsection at any depth: a numbered clause such as 14.3c(1) or 14.3c(1)(a),
+// or an exception or note
 type RuleSubsection struct {
-	Number          string   `json:"number"`
-	Title           string   `json:"title"`
-	Content         string   `json:"content"`
-	PageNumber      int      `json:"page_number"`
-	Path            string   `json:"path"`
-	CrossReferences []string `json:"cross_references,omitempty"`
+	Number          string                    `json:"number"` // For exceptions and notes, the number of the part they belong to
+	Title           string                    `json:"title"`
+	Kind            string                    `json:"kind,omitempty"` // ChunkTypeException or ChunkTypeNote; empty for numbered parts
+	Content         string                    `json:"content"`
+	PageNumber      int                       `json:"page_number"`
+	EndPage         int                       `json:"end_page,omitempty"`
+	Path            string                    `json:"path"`
+	Clauses         map[string]RuleSubsection `json:"clauses,omitempty"` // Keyed by number, or by label for exceptions and notes
+	CrossReferences []string                  `json:"cross_references,omitempty"`
 }
 
 // Query represents a user query
@@ -61,9 +152,26 @@ type Query struct {
 
 // Response represents the response from the LLM
 type Response struct {
-	Answer    string      `json:"answer"`
-	Sources   []TextChunk `json:"sources"`
-	Timestamp string      `json:"timestamp"`
+	Answer         string      `json:"answer"`
+	Sources        []TextChunk `json:"sources"`
+	Timestamp      string      `json:"timestamp"`
+	RewrittenQuery string      `json:"rewritten_query,omitempty"` // Standalone form of a follow-up question, used for retrieval
+	Citations      []Citation  `json:"citations,omitempty"`       // Rules cited in the answer
+	Penalties      []Penalty   `json:"penalties,omitempty"`       // Penalties stated by the sources
+	Definitions    []TextChunk `json:"definitions,omitempty"`     // Definitions of the terms used, added to the sources
+	GroundingScore float64     `json:"grounding_score"`           // Fraction of citations supported by the sources
+}
+
+// Citation is a rule cited in an answer and whether the sources support it
+type Citation struct {
+	Reference string `json:"reference"` // Cited rule (e.g., "Rule 11.2b(1)")
+	Status    string `json:"status"`    // "supported", "not_retrieved" or "unknown"
+}
+
+// ConversationTurn is a question and the answer given to it earlier in a conversation
+type ConversationTurn struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
 }
 
 // IndexEntry represents an entry in the rules index
@@ -71,3 +179,38 @@ type IndexEntry struct {
 	Term           string   `json:"term"`
 	RuleReferences []string `json:"rule_references"`
 }
+
+// EmbeddingInfo records the embedding model an index was built with
+type EmbeddingInfo struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+}
+
+// IndexInfo describes a named index of chunks, such as one rulebook edition
+// embedded with one model
+type IndexInfo struct {
+	Name         string        `json:"name"`
+	Edition      string        `json:"edition,omitempty"` // Rulebook edition (e.g., "2023")
+	Embedding    EmbeddingInfo `json:"embedding"`
+	ChunkSize    int           `json:"chunk_size"`
+	ChunkOverlap int           `json:"chunk_overlap"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ChunkCount   int           `json:"chunk_count"`
+}
+
+// String describes the index on a single line
+func (i IndexInfo) String() string {
+	edition := i.Edition
+	if edition == "" {
+		edition = "N/A"
+	}
+	model := i.Embedding.Model
+	if model == "" {
+		model = "unknown"
+	}
+
+	return fmt.Sprintf("%-24s edition %-6s %s/%s (%d dims)  chunks %d/%d  %d chunks  created %s",
+		i.Name, edition, i.Embedding.Provider, model, i.Embedding.Dimension,
+		i.ChunkSize, i.ChunkOverlap, i.ChunkCount, i.CreatedAt.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client talks to servers implementing the OpenAI HTTP API, such as
+// llama.cpp server, vLLM or LocalAI
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL. The base URL may
+// be given with or without the trailing /v1.
+func NewClient(baseURL, apiKey string) *Client {
+	baseURL = strings.TrimRight(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+
+	return &Client{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// EmbeddingRequest is the body of a /v1/embeddings request
+type EmbeddingRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+// EmbeddingResponse is the body of a /v1/embeddings response
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Model string `json:"model"`
+}
+
+// ChatMessage is a single message in a chat completion
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is the body of a /v1/chat/completions request
+type ChatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+// ChatResponse is the body of a /v1/chat/completions response
+type ChatResponse struct {
+	Choices []struct {
+		Index        int         `json:"index"`
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Model string `json:"model"`
+}
+
+// ChatStreamChunk is one server-sent event of a streamed chat completion
+type ChatStreamChunk struct {
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// RerankRequest is the body of a /v1/rerank request, as served by
+// llama.cpp server, vLLM and LocalAI for cross-encoder reranking models
+type RerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+// RerankResponse is the body of a /v1/rerank response
+type RerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+	Model string `json:"model"`
+}
+
+// errorResponse is the error body returned by OpenAI-compatible servers
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Embeddings creates an embedding for the request input
+func (c *Client) Embeddings(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	var resp EmbeddingResponse
+	if err := c.do(ctx, "/v1/embeddings", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("embedding response contained no data")
+	}
+	return &resp, nil
+}
+
+// ChatCompletion generates a chat completion
+func (c *Client) ChatCompletion(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	var resp ChatResponse
+	if err := c.do(ctx, "/v1/chat/completions", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("chat completion response contained no choices")
+	}
+	return &resp, nil
+}
+
+// ChatCompletionStream generates a chat completion, calling onDelta with each
+// piece of content as the server streams it
+func (c *Client) ChatCompletionStream(ctx context.Context, req *ChatRequest, onDelta func(string) error) error {
+	streamReq := *req
+	streamReq.Stream = true
+
+	httpResp, err := c.post(ctx, "/v1/chat/completions", &streamReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	// Each event is a "data: {json}" line; the stream ends with "data: [DONE]"
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk ChatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream event: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream from /v1/chat/completions: %w", err)
+	}
+
+	return nil
+}
+
+// Rerank scores each document's relevance to the query
+func (c *Client) Rerank(ctx context.Context, req *RerankRequest) (*RerankResponse, error) {
+	var resp RerankResponse
+	if err := c.do(ctx, "/v1/rerank", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do posts reqData as JSON to path and decodes the JSON response into respData
+func (c *Client) do(ctx context.Context, path string, reqData, respData any) error {
+	httpResp, err := c.post(ctx, path, reqData)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(respBody, respData); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// post sends reqData as JSON to path and returns the response, turning
+// statuses other than 2xx into errors. The caller must close the response body.
+func (c *Client) post(ctx context.Context, path string, reqData any) (*http.Response, error) {
+	body, err := json.Marshal(reqData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+
+		var apiErr errorResponse
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("%s returned %s: %s", path, httpResp.Status, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("%s returned %s: %s", path, httpResp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return httpResp, nil
+}
@@ -0,0 +1,226 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves handler at path, checking the request is an
+// authorised JSON POST, and returns a client for it
+func newTestServer(t *testing.T, path string, handler func(w http.ResponseWriter, body map[string]any)) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("request = %s %s, want POST %s", r.Method, r.URL.Path, path)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		handler(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	// The client accepts base URLs with the /v1 suffix
+	return NewClient(server.URL+"/v1/", "secret")
+}
+
+func TestEmbeddings(t *testing.T) {
+	client := newTestServer(t, "/v1/embeddings", func(w http.ResponseWriter, body map[string]any) {
+		if body["model"] != "nomic-embed-text" || body["input"] != "loose impediment" {
+			t.Errorf("request body = %v", body)
+		}
+		fmt.Fprint(w, `{"data": [{"index": 0, "embedding": [0.1, -0.2, 0.3]}], "model": "nomic-embed-text"}`)
+	})
+
+	resp, err := client.Embeddings(context.Background(), &EmbeddingRequest{Model: "nomic-embed-text", Input: "loose impediment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.1, -0.2, 0.3}; !reflect.DeepEqual(resp.Data[0].Embedding, want) {
+		t.Errorf("embedding = %v, want %v", resp.Data[0].Embedding, want)
+	}
+}
+
+func TestEmbeddingsNoData(t *testing.T) {
+	client := newTestServer(t, "/v1/embeddings", func(w http.ResponseWriter, body map[string]any) {
+		fmt.Fprint(w, `{"data": [], "model": "nomic-embed-text"}`)
+	})
+
+	if _, err := client.Embeddings(context.Background(), &EmbeddingRequest{Input: "bunker"}); err == nil {
+		t.Error("Embeddings() with no data succeeded")
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	client := newTestServer(t, "/v1/chat/completions", func(w http.ResponseWriter, body map[string]any) {
+		messages, _ := body["messages"].([]any)
+		if body["model"] != "phi3-mini" || len(messages) != 2 || body["stream"] != nil {
+			t.Errorf("request body = %v", body)
+		}
+		fmt.Fprint(w, `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "Rule 13.2a applies."}, "finish_reason": "stop"}]}`)
+	})
+
+	resp, err := client.ChatCompletion(context.Background(), &ChatRequest{
+		Model: "phi3-mini",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "You answer golf rules questions."},
+			{Role: "user", Content: "Can I leave the flagstick in?"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "Rule 13.2a applies." {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			name:   "error body",
+			status: http.StatusBadRequest,
+			body:   `{"error": {"message": "model not found"}}`,
+			want:   "/v1/chat/completions returned 400 Bad Request: model not found",
+		},
+		{
+			name:   "plain body",
+			status: http.StatusInternalServerError,
+			body:   "upstream crashed\n",
+			want:   "/v1/chat/completions returned 500 Internal Server Error: upstream crashed",
+		},
+		{
+			name:   "JSON without a message",
+			status: http.StatusServiceUnavailable,
+			body:   `{"status": "loading"}`,
+			want:   `/v1/chat/completions returned 503 Service Unavailable: {"status": "loading"}`,
+		},
+		{
+			name:   "non-2xx success-like status",
+			status: http.StatusNotModified,
+			want:   "/v1/chat/completions returned 304 Not Modified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestServer(t, "/v1/chat/completions", func(w http.ResponseWriter, body map[string]any) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := client.ChatCompletion(context.Background(), &ChatRequest{Model: "phi3-mini"})
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("ChatCompletion() error = %v, want %q", err, tt.want)
+			}
+
+			err = client.ChatCompletionStream(context.Background(), &ChatRequest{Model: "phi3-mini"},
+				func(string) error { return nil })
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("ChatCompletionStream() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "data lines until done",
+			events: "data: {\"choices\": [{\"delta\": {\"role\": \"assistant\"}}]}\n\n" +
+				"data: {\"choices\": [{\"delta\": {\"content\": \"Rule \"}}]}\n\n" +
+				": keep-alive comment\n\n" +
+				"data:{\"choices\": [{\"delta\": {\"content\": \"13.2a\"}}]}\n\n" +
+				"data: [DONE]\n\n" +
+				"data: {\"choices\": [{\"delta\": {\"content\": \" ignored\"}}]}\n\n",
+			want: []string{"Rule ", "13.2a"},
+		},
+		{
+			name:   "stream ending without done",
+			events: "data: {\"choices\": [{\"delta\": {\"content\": \"Rule 13\"}, \"finish_reason\": \"stop\"}]}\n\n",
+			want:   []string{"Rule 13"},
+		},
+		{
+			name: "malformed chunk",
+			events: "data: {\"choices\": [{\"delta\": {\"content\": \"Rule \"}}]}\n\n" +
+				"data: {\"choices\": [{\"delta\": \n\n" +
+				"data: [DONE]\n\n",
+			want:    []string{"Rule "},
+			wantErr: "failed to decode stream event",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestServer(t, "/v1/chat/completions", func(w http.ResponseWriter, body map[string]any) {
+				if body["stream"] != true {
+					t.Errorf("stream = %v, want true", body["stream"])
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, tt.events)
+			})
+
+			var got []string
+			err := client.ChatCompletionStream(context.Background(), &ChatRequest{Model: "phi3-mini"},
+				func(delta string) error {
+					got = append(got, delta)
+					return nil
+				})
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ChatCompletionStream() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("deltas = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatCompletionStreamCallbackError(t *testing.T) {
+	client := newTestServer(t, "/v1/chat/completions", func(w http.ResponseWriter, body map[string]any) {
+		fmt.Fprint(w, "data: {\"choices\": [{\"delta\": {\"content\": \"Rule \"}}]}\n\n"+
+			"data: {\"choices\": [{\"delta\": {\"content\": \"13\"}}]}\n\n")
+	})
+
+	stop := errors.New("stop")
+	calls := 0
+	err := client.ChatCompletionStream(context.Background(), &ChatRequest{}, func(string) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("ChatCompletionStream() = %v after %d calls, want the callback error after 1", err, calls)
+	}
+}
@@ -0,0 +1,200 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+)
+
+var (
+	// letteredRe matches the number of a lettered subsection, e.g. "14.3c"
+	letteredRe = regexp.MustCompile(`^\d+\.\d+[a-z]$`)
+
+	// numberedItemRe matches numbered clauses of a lettered subsection, e.g.
+	// "(1) Ball Must Come to Rest in Relief Area", with or without the
+	// subsection's number in front
+	numberedItemRe = regexp.MustCompile(`(?m)^` + headingMark + `?(?:\d+\.\d+[a-z])?\((\d+)\)\s+(.+?)$`)
+
+	// letteredItemRe matches lettered clauses of a numbered clause, e.g.
+	// "(a) Ball in General Area", with or without the clause's number in front
+	letteredItemRe = regexp.MustCompile(`(?m)^` + headingMark + `?(?:\d+\.\d+[a-z](?:\(\d+\))+)?\(([a-z])\)\s+(.+?)$`)
+
+	// exceptionRe matches the start of an exception or note, e.g.
+	// "Exception 2 – Ball Moved by Wind" or "Note: ..."
+	exceptionRe = regexp.MustCompile(`(?m)^` + headingMark + `?(Exception|Note)(?:\s+(\d+))?\s*(?:[–—:-]\s*(.*?))?$`)
+)
+
+// extractClauses returns the parts of a section or subsection below it.
+// Lettered subsections such as 14.3c are divided into numbered clauses,
+// 14.3c(1), and those into lettered clauses, 14.3c(1)(a). Clauses are only
+// taken in sequence, from (1) or (a), so that a list item or a wrapped line
+// starting with a number in brackets is not mistaken for one. Exceptions
+// and notes belong to the innermost part they appear in. Pages are counted
+// from firstPage, the page text starts on.
+func (p *PDFProcessor) extractClauses(text, number, path string, firstPage int) map[string]models.RuleSubsection {
+	clauses := make(map[string]models.RuleSubsection)
+
+	// The part's own text ends where its first clause starts
+	own := len(text)
+	if re := clausePattern(number); re != nil {
+		matches := inSequence(text, re.FindAllStringSubmatchIndex(text, -1))
+		if len(matches) > 0 {
+			own = matches[0][0]
+		}
+
+		for i, match := range matches {
+			start, end := match[0], len(text)
+			if i < len(matches)-1 {
+				end = matches[i+1][0]
+			}
+
+			clauseNum := number + "(" + text[match[2]:match[3]] + ")"
+			clauseText := text[start:end]
+			clausePage, clauseLastPage := pageSpan(text, start, end, firstPage)
+			clause := models.RuleSubsection{
+				Number:     clauseNum,
+				Title:      strings.TrimSpace(text[match[4]:match[5]]),
+				Content:    clauseText,
+				PageNumber: clausePage,
+				EndPage:    endPage(clausePage, clauseLastPage),
+				Path:       fmt.Sprintf("%s > %s", path, clauseNum),
+			}
+			clause.Clauses = p.extractClauses(clauseText, clauseNum, clause.Path, clausePage)
+			clauses[clauseNum] = clause
+		}
+	}
+
+	// Exceptions and notes run to the next one or to the first clause
+	ownText := text[:own]
+	matches := exceptionRe.FindAllStringSubmatchIndex(ownText, -1)
+	for i, match := range matches {
+		start, end := match[0], len(ownText)
+		if i < len(matches)-1 {
+			end = matches[i+1][0]
+		}
+
+		kind := models.ChunkTypeException
+		if ownText[match[2]:match[3]] == "Note" {
+			kind = models.ChunkTypeNote
+		}
+
+		label := ownText[match[2]:match[3]]
+		if match[4] >= 0 {
+			label += " " + ownText[match[4]:match[5]]
+		}
+		if _, taken := clauses[label]; taken {
+			label += fmt.Sprintf(" (%d)", i+1)
+		}
+
+		title := ""
+		if kind == models.ChunkTypeException && match[6] >= 0 {
+			title = strings.TrimSpace(ownText[match[6]:match[7]])
+		}
+
+		clausePage, clauseLastPage := pageSpan(ownText, start, end, firstPage)
+		clauses[label] = models.RuleSubsection{
+			Number:     number,
+			Title:      title,
+			Kind:       kind,
+			Content:    ownText[start:end],
+			PageNumber: clausePage,
+			EndPage:    endPage(clausePage, clauseLastPage),
+			Path:       fmt.Sprintf("%s > %s", path, label),
+		}
+	}
+
+	if len(clauses) == 0 {
+		return nil
+	}
+	return clauses
+}
+
+// clausePattern returns the pattern for the clauses directly below a part
+// of a rule, or nil if the part has no numbered clauses
+func clausePattern(number string) *regexp.Regexp {
+	switch {
+	case letteredRe.MatchString(number):
+		return numberedItemRe
+	case isNumberedClause(number):
+		return letteredItemRe
+	default:
+		return nil
+	}
+}
+
+// isNumberedClause reports whether a number ends with a numbered clause, e.g. 14.3c(1)
+func isNumberedClause(number string) bool {
+	open := strings.LastIndex(number, "(")
+	if open < 0 || !strings.HasSuffix(number, ")") {
+		return false
+	}
+	_, err := strconv.Atoi(number[open+1 : len(number)-1])
+	return err == nil
+}
+
+// inSequence keeps the clause matches numbered in order from (1) or (a),
+// skipping any out of sequence
+func inSequence(text string, matches [][]int) [][]int {
+	var kept [][]int
+	for _, match := range matches {
+		if clauseOrdinal(text[match[2]:match[3]]) == len(kept)+1 {
+			kept = append(kept, match)
+		}
+	}
+	return kept
+}
+
+// clauseOrdinal returns the position of a clause number such as "2" or "b"
+// in its sequence, starting from 1
+func clauseOrdinal(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if len(s) == 1 && s[0] >= 'a' && s[0] <= 'z' {
+		return int(s[0]-'a') + 1
+	}
+	return 0
+}
+
+// appendClauseChunks adds a chunk for every subsection, clause, exception
+// and note in clauses and below them, at any depth, and returns the next
+// chunk ID
+func appendClauseChunks(chunks []models.TextChunk, clauses map[string]models.RuleSubsection,
+	ruleNum string, rule models.GolfRuleHierarchy, chunkID int) ([]models.TextChunk, int) {
+
+	for label, clause := range clauses {
+		chunkType := clause.Kind
+		if chunkType == "" {
+			chunkType = "subsection"
+		}
+		title := clause.Title
+		if title == "" {
+			title = label
+		}
+
+		chunks = append(chunks, models.TextChunk{
+			ID:      chunkID,
+			Content: stripMarkers(clause.Content),
+			Metadata: models.Metadata{
+				PageNumber:  clause.PageNumber,
+				EndPage:     clause.EndPage,
+				Section:     ruleNum,
+				Title:       rule.Title,
+				Subsection:  clause.Number,
+				SubsecTitle: title,
+				Hierarchy:   clause.Path,
+				ParentRule:  ruleNum,
+				ChunkType:   chunkType,
+			},
+			IndexTerms: rule.IndexTerms,
+		})
+		chunkID++
+
+		chunks, chunkID = appendClauseChunks(chunks, clause.Clauses, ruleNum, rule, chunkID)
+	}
+
+	return chunks, chunkID
+}
@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+// clauseSummary is what a test checks of an extracted clause
+type clauseSummary struct {
+	number, kind, title string
+	content             string // Trimmed, without heading marks
+}
+
+// flattenClauses returns the clauses at every depth by path
+func flattenClauses(clauses map[string]models.RuleSubsection, flat map[string]clauseSummary) map[string]clauseSummary {
+	if flat == nil {
+		flat = make(map[string]clauseSummary)
+	}
+	for _, clause := range clauses {
+		flat[clause.Path] = clauseSummary{clause.Number, clause.Kind, clause.Title,
+			strings.TrimSpace(stripMarkers(clause.Content))}
+		flattenClauses(clause.Clauses, flat)
+	}
+	return flat
+}
+
+func TestExtractClauses(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		text   string
+		want   map[string]clauseSummary
+	}{
+		{
+			name:   "nested clauses",
+			number: "14.3c",
+			text: "14.3c Ball Must Be Dropped in Relief Area\n" +
+				"(1) Ball Must Come to Rest in Relief Area\nIt must stay in.\n" +
+				"(a) Ball in General Area\nIn the general area.\n" +
+				"(b) Ball in Bunker\nIn the bunker.\n" +
+				"(2) What to Do If Ball Comes to Rest Outside Relief Area\nDrop again.",
+			want: map[string]clauseSummary{
+				"R > 14.3c(1)": {"14.3c(1)", "", "Ball Must Come to Rest in Relief Area",
+					"(1) Ball Must Come to Rest in Relief Area\nIt must stay in.\n" +
+						"(a) Ball in General Area\nIn the general area.\n(b) Ball in Bunker\nIn the bunker."},
+				"R > 14.3c(1) > 14.3c(1)(a)": {"14.3c(1)(a)", "", "Ball in General Area",
+					"(a) Ball in General Area\nIn the general area."},
+				"R > 14.3c(1) > 14.3c(1)(b)": {"14.3c(1)(b)", "", "Ball in Bunker", "(b) Ball in Bunker\nIn the bunker."},
+				"R > 14.3c(2)": {"14.3c(2)", "", "What to Do If Ball Comes to Rest Outside Relief Area",
+					"(2) What to Do If Ball Comes to Rest Outside Relief Area\nDrop again."},
+			},
+		},
+		{
+			name:   "marked clauses numbered in full",
+			number: "14.3c",
+			text: headingMark + "14.3c Ball Must Be Dropped in Relief Area\n" +
+				headingMark + "14.3c(1) Ball Must Come to Rest in Relief Area\nIt must stay in.\n" +
+				headingMark + "14.3c(1)(a) Ball in General Area\nIn the general area.",
+			want: map[string]clauseSummary{
+				"R > 14.3c(1)": {"14.3c(1)", "", "Ball Must Come to Rest in Relief Area",
+					"14.3c(1) Ball Must Come to Rest in Relief Area\nIt must stay in.\n" +
+						"14.3c(1)(a) Ball in General Area\nIn the general area."},
+				"R > 14.3c(1) > 14.3c(1)(a)": {"14.3c(1)(a)", "", "Ball in General Area",
+					"14.3c(1)(a) Ball in General Area\nIn the general area."},
+			},
+		},
+		{
+			name:   "clauses out of sequence are text",
+			number: "16.1c",
+			text: "16.1c Relief for Ball in Bunker\n" +
+				"(1) Free Relief in Bunker\nThe player may take relief as in\n(3) below, or drop.\n" +
+				"(2) Back-On-the-Line Relief\nFor one penalty stroke.",
+			want: map[string]clauseSummary{
+				"R > 16.1c(1)": {"16.1c(1)", "", "Free Relief in Bunker",
+					"(1) Free Relief in Bunker\nThe player may take relief as in\n(3) below, or drop."},
+				"R > 16.1c(2)": {"16.1c(2)", "", "Back-On-the-Line Relief", "(2) Back-On-the-Line Relief\nFor one penalty stroke."},
+			},
+		},
+		{
+			name:   "exceptions and notes belong to the innermost part",
+			number: "14.3c",
+			text: "14.3c Ball Must Be Dropped in Relief Area\nThe drop must be made properly.\n" +
+				"Note: A ball dropped in the wrong way must be dropped again.\n" +
+				"(1) Ball Must Come to Rest in Relief Area\nIt must stay in.\n" +
+				"Exception – Ball Rolls Out\nThe player may place the ball.\n" +
+				"(2) What to Do If Ball Comes to Rest Outside Relief Area\nDrop again.",
+			want: map[string]clauseSummary{
+				"R > Note": {"14.3c", models.ChunkTypeNote, "",
+					"Note: A ball dropped in the wrong way must be dropped again."},
+				"R > 14.3c(1)": {"14.3c(1)", "", "Ball Must Come to Rest in Relief Area",
+					"(1) Ball Must Come to Rest in Relief Area\nIt must stay in.\n" +
+						"Exception – Ball Rolls Out\nThe player may place the ball."},
+				"R > 14.3c(1) > Exception": {"14.3c(1)", models.ChunkTypeException, "Ball Rolls Out",
+					"Exception – Ball Rolls Out\nThe player may place the ball."},
+				"R > 14.3c(2)": {"14.3c(2)", "", "What to Do If Ball Comes to Rest Outside Relief Area",
+					"(2) What to Do If Ball Comes to Rest Outside Relief Area\nDrop again."},
+			},
+		},
+		{
+			name:   "numbered exceptions and repeated notes of a section",
+			number: "9.4",
+			text: "9.4 Ball Lifted or Moved by Player\nThe player gets one penalty stroke.\n" +
+				headingMark + "Exception 1 – Ball Moved While Searching\nNo penalty.\n" +
+				headingMark + "Exception 2 – Ball Moved Accidentally on Putting Green\nNo penalty either.\n" +
+				"Note: Replace the ball.\nNote: See Rule 14.2.",
+			want: map[string]clauseSummary{
+				"R > Exception 1": {"9.4", models.ChunkTypeException, "Ball Moved While Searching",
+					"Exception 1 – Ball Moved While Searching\nNo penalty."},
+				"R > Exception 2": {"9.4", models.ChunkTypeException, "Ball Moved Accidentally on Putting Green",
+					"Exception 2 – Ball Moved Accidentally on Putting Green\nNo penalty either."},
+				"R > Note":     {"9.4", models.ChunkTypeNote, "", "Note: Replace the ball."},
+				"R > Note (4)": {"9.4", models.ChunkTypeNote, "", "Note: See Rule 14.2."},
+			},
+		},
+		{
+			name:   "no clauses",
+			number: "13.1a",
+			text:   "13.1a When Ball Is on Putting Green\nA ball is on the putting green when it touches it.",
+		},
+	}
+
+	p := NewPDFProcessor(0, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenClauses(p.extractClauses(tt.text, tt.number, "R", 1), nil)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractClauses() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
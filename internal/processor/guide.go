@@ -0,0 +1,188 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+)
+
+// modelLocalRuleID matches the identifier of a Model Local Rule, e.g. "E-5"
+const modelLocalRuleID = `[A-L]-\d+(?:\.\d+)?`
+
+var (
+	// interpretationRe matches the heading of an interpretation of the
+	// Official Guide, e.g. "Interpretation 10.2b/1 – Caddie May Not..." or
+	// "10.2b/1 – Caddie May Not...", capturing the rule number it
+	// interprets, its number and its title
+	interpretationRe = regexp.MustCompile(`(?m)^` + headingMark +
+		`?(?:Interpretation\s+)?(\d+(?:\.\d+[a-z]?(?:\(\d+\))*)?)/(\d+)\s*[–—-]\s*(.+?)$`)
+
+	// modelLocalRuleHeadingRe matches a line starting like the heading of a
+	// Model Local Rule, e.g. "Model Local Rule E-5" or "E-5 Alternative to
+	// Stroke and Distance...", which is its heading when set like one
+	modelLocalRuleHeadingRe = regexp.MustCompile(`^(?:Model Local Rule\s+` + modelLocalRuleID + `\b|` +
+		modelLocalRuleID + `\s+(?:[–—-]\s*)?[A-Z])`)
+
+	// committeeSectionRe matches the headings of the other sections of the
+	// Committee Procedures, e.g. "Section 9 – Code of Player Conduct", which
+	// end the Model Local Rule before them
+	committeeSectionRe = regexp.MustCompile(`(?m)^` + headingMark + `?Section\s+\d+[A-Z]?\b.*$`)
+
+	// guideEndRe matches the starts of the definitions and the index, which
+	// end an interpretation or Model Local Rule before them
+	guideEndRe = regexp.MustCompile(`(?m)^` + headingMark + `?(?:XI\.\s+Definitions|Definitions|Index)\s*$`)
+
+	// rulePartRe matches the parts of a rule number after its section, e.g.
+	// "c" and "(1)" in 14.3c(1)
+	rulePartRe = regexp.MustCompile(`[a-z]|\([^)]*\)`)
+)
+
+// guideBlock is an interpretation or Model Local Rule found in the text
+type guideBlock struct {
+	start, end int
+	chunk      models.TextChunk
+}
+
+// extractOfficialGuide finds the interpretations and Model Local Rules of
+// the Official Guide to the Rules of Golf in text. Each runs from its
+// heading to the next heading of a rule, section, subsection, interpretation
+// or Model Local Rule. They are returned as chunks of their own, and removed
+// from the text, keeping its page breaks, so that they are not taken for
+// rule text. Interpretations carry the number of the rule they interpret,
+// linking them to it.
+func (p *PDFProcessor) extractOfficialGuide(text string, firstPage int) (string, []models.TextChunk) {
+	var blocks []guideBlock
+
+	for _, match := range interpretationRe.FindAllStringSubmatchIndex(text, -1) {
+		parent := text[match[2]:match[3]]
+		id := parent + "/" + text[match[4]:match[5]]
+		rule := "Rule " + strings.SplitN(parent, ".", 2)[0]
+
+		subsection := ""
+		if strings.Contains(parent, ".") {
+			subsection = parent
+		}
+
+		blocks = append(blocks, guideBlock{start: match[0], chunk: models.TextChunk{
+			Metadata: models.Metadata{
+				Section:     rule,
+				Subsection:  subsection,
+				SubsecTitle: strings.TrimSpace(text[match[6]:match[7]]),
+				Hierarchy:   fmt.Sprintf("%s > Interpretation %s", rulePath(parent), id),
+				ParentRule:  rule,
+				ChunkType:   models.ChunkTypeInterpretation,
+			},
+		}})
+	}
+
+	for _, match := range modelLocalRulePattern(text).FindAllStringSubmatchIndex(text, -1) {
+		id, title := "", ""
+		if match[2] >= 0 {
+			id, title = text[match[2]:match[3]], text[match[4]:match[5]]
+		} else {
+			id, title = text[match[6]:match[7]], text[match[8]:match[9]]
+		}
+		name := "Model Local Rule " + id
+
+		blocks = append(blocks, guideBlock{start: match[0], chunk: models.TextChunk{
+			Metadata: models.Metadata{
+				Section:   name,
+				Title:     strings.TrimSpace(title),
+				Hierarchy: "Committee Procedures > " + name,
+				ChunkType: models.ChunkTypeModelLocalRule,
+			},
+		}})
+	}
+
+	if len(blocks) == 0 {
+		return text, nil
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].start < blocks[j].start
+	})
+
+	// Every heading ends the block before it
+	patterns := patternsFor(text)
+	var boundaries []int
+	for _, re := range []*regexp.Regexp{patterns.rule, patterns.section, patterns.subsection,
+		committeeSectionRe, guideEndRe} {
+		for _, match := range re.FindAllStringIndex(text, -1) {
+			boundaries = append(boundaries, match[0])
+		}
+	}
+	for _, block := range blocks {
+		boundaries = append(boundaries, block.start)
+	}
+	sort.Ints(boundaries)
+
+	chunks := make([]models.TextChunk, 0, len(blocks))
+	for i := range blocks {
+		block := &blocks[i]
+		block.end = len(text)
+		if next := sort.SearchInts(boundaries, block.start+1); next < len(boundaries) {
+			block.end = boundaries[next]
+		}
+
+		first, last := pageSpan(text, block.start, block.end, firstPage)
+		chunk := block.chunk
+		chunk.ID = i + 1
+		chunk.Content = stripMarkers(text[block.start:block.end])
+		chunk.Metadata.PageNumber = first
+		chunk.Metadata.EndPage = endPage(first, last)
+		chunks = append(chunks, chunk)
+	}
+
+	// Remove the blocks from the end so earlier offsets stay valid
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		breaks := strings.Count(text[block.start:block.end], "\f")
+		text = text[:block.start] + "\n" + strings.Repeat("\f\n", breaks) + text[block.end:]
+	}
+
+	return text, chunks
+}
+
+// modelLocalRulePattern returns the pattern for the headings of Model Local
+// Rules in text, e.g. "Model Local Rule E-5" or "E-5 Alternative to Stroke
+// and Distance...", capturing the identifier and title of each with or
+// without the "Model Local Rule" prefix. Bare identifiers must be followed
+// by a title so that wrapped references are not taken as headings. Text
+// with headings detected from their font only matches those; otherwise a
+// heading must not end like a sentence, e.g. "E-5 Alternative to Stroke and
+// Distance may apply."
+func modelLocalRulePattern(text string) *regexp.Regexp {
+	prefix, title, bareTitle := "", `((?:.*[^.?!,;:\s])?)`, `([A-Z](?:.*[^.?!,;:\s])?)`
+	if strings.Contains(text, headingMark) {
+		prefix, title, bareTitle = headingMark, `(.*?)`, `([A-Z].*?)`
+	}
+
+	return regexp.MustCompile(`(?m)^` + prefix + `(?:Model Local Rule\s+(` + modelLocalRuleID +
+		`)\s*[–—:.-]?\s*` + title + `|(` + modelLocalRuleID + `)\s+(?:[–—-]\s*)?` + bareTitle + `)$`)
+}
+
+// rulePath returns the hierarchy path of a rule number, e.g.
+// "Rule 14 > 14.3 > 14.3c > 14.3c(1)" for 14.3c(1)
+func rulePath(number string) string {
+	main, rest, dotted := strings.Cut(number, ".")
+	path := "Rule " + main
+	if !dotted {
+		return path
+	}
+
+	// Section, then lettered subsection, then each clause
+	digits := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		return path + " > " + number
+	}
+	part := main + "." + rest[:digits]
+	path += " > " + part
+
+	for _, piece := range rulePartRe.FindAllString(rest[digits:], -1) {
+		part += piece
+		path += " > " + part
+	}
+	return path
+}
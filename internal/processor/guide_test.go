@@ -0,0 +1,251 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestExtractOfficialGuide(t *testing.T) {
+	type block struct {
+		metadata models.Metadata
+		content  string
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		blocks []block
+		rest   string // Text left for the rules
+	}{
+		{
+			name: "interpretation ends at the next section",
+			text: "Rule 10 – Preparing for and Making a Stroke\n" +
+				"10.2b Other Help\nA caddie must not stand behind the player.\n" +
+				"10.2b/1 – Caddie May Not Stand Behind Player\nThe restriction applies throughout the stroke.\n" +
+				"10.3 Caddies\nA player may have a caddie.",
+			blocks: []block{{
+				metadata: models.Metadata{
+					Section:     "Rule 10",
+					Subsection:  "10.2b",
+					SubsecTitle: "Caddie May Not Stand Behind Player",
+					Hierarchy:   "Rule 10 > 10.2 > 10.2b > Interpretation 10.2b/1",
+					ParentRule:  "Rule 10",
+					PageNumber:  1,
+					ChunkType:   models.ChunkTypeInterpretation,
+				},
+				content: "10.2b/1 – Caddie May Not Stand Behind Player\nThe restriction applies throughout the stroke.",
+			}},
+			rest: "Rule 10 – Preparing for and Making a Stroke\n" +
+				"10.2b Other Help\nA caddie must not stand behind the player.\n\n" +
+				"10.3 Caddies\nA player may have a caddie.",
+		},
+		{
+			name: "interpretations end at each other",
+			text: "Interpretation 14/1 – Player Must Know Ball\nFirst.\n" +
+				"Interpretation 14.3c(1)/2 – Dropping Twice\nSecond.\f\nOn the next page.\n" +
+				"Rule 15 – Relief from Loose Impediments",
+			blocks: []block{
+				{
+					metadata: models.Metadata{
+						Section:     "Rule 14",
+						SubsecTitle: "Player Must Know Ball",
+						Hierarchy:   "Rule 14 > Interpretation 14/1",
+						ParentRule:  "Rule 14",
+						PageNumber:  1,
+						ChunkType:   models.ChunkTypeInterpretation,
+					},
+					content: "Interpretation 14/1 – Player Must Know Ball\nFirst.",
+				},
+				{
+					metadata: models.Metadata{
+						Section:     "Rule 14",
+						Subsection:  "14.3c(1)",
+						SubsecTitle: "Dropping Twice",
+						Hierarchy:   "Rule 14 > 14.3 > 14.3c > 14.3c(1) > Interpretation 14.3c(1)/2",
+						ParentRule:  "Rule 14",
+						PageNumber:  1,
+						EndPage:     2,
+						ChunkType:   models.ChunkTypeInterpretation,
+					},
+					content: "Interpretation 14.3c(1)/2 – Dropping Twice\nSecond.\n\nOn the next page.",
+				},
+			},
+			rest: "\n\n\f\nRule 15 – Relief from Loose Impediments",
+		},
+		{
+			name: "Model Local Rule ends at a Committee Procedures section",
+			text: "Model Local Rule E-5 – Alternative to Stroke and Distance\n" +
+				"This Local Rule allows a player to drop in the fairway.\n" +
+				"Section 9 – Code of Player Conduct\nThe Committee may adopt a code.",
+			blocks: []block{{
+				metadata: models.Metadata{
+					Section:    "Model Local Rule E-5",
+					Title:      "Alternative to Stroke and Distance",
+					Hierarchy:  "Committee Procedures > Model Local Rule E-5",
+					PageNumber: 1,
+					ChunkType:  models.ChunkTypeModelLocalRule,
+				},
+				content: "Model Local Rule E-5 – Alternative to Stroke and Distance\n" +
+					"This Local Rule allows a player to drop in the fairway.",
+			}},
+			rest: "\nSection 9 – Code of Player Conduct\nThe Committee may adopt a code.",
+		},
+		{
+			name: "bare Model Local Rules end at each other and the definitions",
+			text: "F-1 Abnormal Course Conditions\nFirst.\nF-2.1 Ground Under Repair\nSecond.\nDefinitions\nAbnormal Course Condition",
+			blocks: []block{
+				{
+					metadata: models.Metadata{
+						Section:    "Model Local Rule F-1",
+						Title:      "Abnormal Course Conditions",
+						Hierarchy:  "Committee Procedures > Model Local Rule F-1",
+						PageNumber: 1,
+						ChunkType:  models.ChunkTypeModelLocalRule,
+					},
+					content: "F-1 Abnormal Course Conditions\nFirst.",
+				},
+				{
+					metadata: models.Metadata{
+						Section:    "Model Local Rule F-2.1",
+						Title:      "Ground Under Repair",
+						Hierarchy:  "Committee Procedures > Model Local Rule F-2.1",
+						PageNumber: 1,
+						ChunkType:  models.ChunkTypeModelLocalRule,
+					},
+					content: "F-2.1 Ground Under Repair\nSecond.",
+				},
+			},
+			rest: "\n\nDefinitions\nAbnormal Course Condition",
+		},
+		{
+			name: "bare identifiers are only headings when marked",
+			text: headingMark + "Rule 18 – Stroke and Distance\n" +
+				headingMark + "E-5 Alternative to Stroke and Distance\n" +
+				"E-5 The Committee may adopt this Local Rule.\n" +
+				headingMark + "Index",
+			blocks: []block{{
+				metadata: models.Metadata{
+					Section:    "Model Local Rule E-5",
+					Title:      "Alternative to Stroke and Distance",
+					Hierarchy:  "Committee Procedures > Model Local Rule E-5",
+					PageNumber: 1,
+					ChunkType:  models.ChunkTypeModelLocalRule,
+				},
+				content: "E-5 Alternative to Stroke and Distance\nE-5 The Committee may adopt this Local Rule.",
+			}},
+			rest: headingMark + "Rule 18 – Stroke and Distance\n\n" + headingMark + "Index",
+		},
+		{
+			name: "prefixed identifiers are only headings when marked",
+			text: headingMark + "Rule 18 – Stroke and Distance\n" +
+				headingMark + "18.1 Relief Under Penalty of Stroke and Distance\n" +
+				"Relief may instead be allowed under\n" +
+				"Model Local Rule E-5 when the Committee adopts it.\n" +
+				"The player must then drop a ball.\n" +
+				headingMark + "Model Local Rule E-5 – Alternative to Stroke and Distance\n" +
+				"This Local Rule allows a player to drop in the fairway.",
+			blocks: []block{{
+				metadata: models.Metadata{
+					Section:    "Model Local Rule E-5",
+					Title:      "Alternative to Stroke and Distance",
+					Hierarchy:  "Committee Procedures > Model Local Rule E-5",
+					PageNumber: 1,
+					ChunkType:  models.ChunkTypeModelLocalRule,
+				},
+				content: "Model Local Rule E-5 – Alternative to Stroke and Distance\n" +
+					"This Local Rule allows a player to drop in the fairway.",
+			}},
+			rest: headingMark + "Rule 18 – Stroke and Distance\n" +
+				headingMark + "18.1 Relief Under Penalty of Stroke and Distance\n" +
+				"Relief may instead be allowed under\n" +
+				"Model Local Rule E-5 when the Committee adopts it.\n" +
+				"The player must then drop a ball.\n\n",
+		},
+		{
+			name: "sentences are not headings",
+			text: "Rule 18 – Stroke and Distance\n18.1 Relief Under Penalty of Stroke and Distance\n" +
+				"E-5 Alternative to Stroke and Distance may apply.\n" +
+				"Model Local Rule E-5 applies when the Committee adopts it.\n" +
+				"The player must then drop a ball.",
+			rest: "Rule 18 – Stroke and Distance\n18.1 Relief Under Penalty of Stroke and Distance\n" +
+				"E-5 Alternative to Stroke and Distance may apply.\n" +
+				"Model Local Rule E-5 applies when the Committee adopts it.\n" +
+				"The player must then drop a ball.",
+		},
+		{
+			name: "wrapped references are not headings",
+			text: "Rule 18 – Stroke and Distance\nRelief is allowed under Model Local Rule\nE-5 when the Committee adopts it.",
+			rest: "Rule 18 – Stroke and Distance\nRelief is allowed under Model Local Rule\nE-5 when the Committee adopts it.",
+		},
+	}
+
+	p := NewPDFProcessor(0, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, chunks := p.extractOfficialGuide(tt.text, 1)
+
+			if rest != tt.rest {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+			if len(chunks) != len(tt.blocks) {
+				t.Fatalf("got %d blocks, want %d: %+v", len(chunks), len(tt.blocks), chunks)
+			}
+			for i, chunk := range chunks {
+				if chunk.Metadata != tt.blocks[i].metadata {
+					t.Errorf("block %d metadata = %+v, want %+v", i, chunk.Metadata, tt.blocks[i].metadata)
+				}
+				if content := strings.TrimSpace(chunk.Content); content != tt.blocks[i].content {
+					t.Errorf("block %d content = %q, want %q", i, content, tt.blocks[i].content)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkHeadingsModelLocalRules(t *testing.T) {
+	line := func(text string, size, y float64) Line {
+		return Line{Runs: []TextRun{{Text: text, Font: "Helvetica", FontSize: size, X: 72, Y: y}}, Y: y}
+	}
+	pages := []Page{{Number: 1, Lines: []Line{
+		line("Rule 18 – Stroke and Distance", 14, 750),
+		line("E-5 Alternative to Stroke and Distance", 14, 730),
+		line("E-5 The Committee may adopt this Local Rule when play would be slowed.", 10, 710),
+		line("Model Local Rule E-6 – Lost Ball", 14, 690),
+		line("Model Local Rule E-6 may also be adopted.", 10, 670),
+	}}}
+
+	marked, found := markHeadings(pages)
+	if !found {
+		t.Fatal("markHeadings() found no headings")
+	}
+
+	want := headingMark + "Rule 18 – Stroke and Distance\n" +
+		headingMark + "E-5 Alternative to Stroke and Distance\n" +
+		"E-5 The Committee may adopt this Local Rule when play would be slowed.\n" +
+		headingMark + "Model Local Rule E-6 – Lost Ball\n" +
+		"Model Local Rule E-6 may also be adopted."
+	if got := marked[0].Text(); got != want {
+		t.Errorf("marked text = %q, want %q", got, want)
+	}
+}
+
+func TestRulePath(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"14", "Rule 14"},
+		{"14.3", "Rule 14 > 14.3"},
+		{"14.3c", "Rule 14 > 14.3 > 14.3c"},
+		{"14.3c(1)", "Rule 14 > 14.3 > 14.3c > 14.3c(1)"},
+		{"14.3c(1)(a)", "Rule 14 > 14.3 > 14.3c > 14.3c(1) > 14.3c(1)(a)"},
+	}
+
+	for _, tt := range tests {
+		if got := rulePath(tt.number); got != tt.want {
+			t.Errorf("rulePath(%q) = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// edgeLines is the number of lines at the top and at the bottom of a
+	// page checked for running heads and feet
+	edgeLines = 3
+
+	// repeatedLineShare is the share of pages a line must recur on, in the
+	// same place, to be taken as a running head or foot. Heads alternating
+	// between left and right pages recur on half of them.
+	repeatedLineShare = 0.3
+
+	// repeatedLineMinPages is the fewest pages a line must recur on
+	repeatedLineMinPages = 3
+)
+
+// digitsRe matches the numbers that vary between otherwise identical
+// running heads and feet, such as page numbers
+var digitsRe = regexp.MustCompile(`\d+`)
+
+// RepeatedLine is a running head or foot removed from the pages of a PDF
+type RepeatedLine struct {
+	Text   string // As it appears on the first page it was removed from
+	Bottom bool   // Whether it is a foot rather than a head
+	Pages  int    // Number of pages it was removed from
+}
+
+// edgeLine is a line near the top or bottom of a page
+type edgeLine struct {
+	index int // Line number on the page
+	key   string
+}
+
+// removeHeadersFooters removes the lines recurring at the top or bottom of
+// many pages, such as page numbers, running heads and "Rules of Golf"
+// banners. Lines are compared ignoring case, spacing and numbers, so that
+// "Page 12" and "Page 13" count as the same line. Every page is kept, even
+// if empty, so that page breaks still count pages.
+func removeHeadersFooters(text string) (string, []RepeatedLine) {
+	pages := strings.Split(text, pageBreak)
+	lines := make([][]string, len(pages))
+	edges := make([][]edgeLine, len(pages))
+
+	// Count the pages each line appears on, at the top or at the bottom
+	counts := make(map[string]int)
+	nonEmpty := 0
+	for i, page := range pages {
+		lines[i] = strings.Split(page, "\n")
+		edges[i] = pageEdges(lines[i])
+		if len(edges[i]) > 0 {
+			nonEmpty++
+		}
+
+		seen := make(map[string]bool)
+		for _, edge := range edges[i] {
+			if !seen[edge.key] {
+				seen[edge.key] = true
+				counts[edge.key]++
+			}
+		}
+	}
+
+	minPages := int(math.Ceil(repeatedLineShare * float64(nonEmpty)))
+	if minPages < repeatedLineMinPages {
+		minPages = repeatedLineMinPages
+	}
+
+	removed := make(map[string]*RepeatedLine)
+	for i := range pages {
+		drop := make(map[int]bool)
+		for _, edge := range edges[i] {
+			if counts[edge.key] < minPages {
+				continue
+			}
+			drop[edge.index] = true
+
+			if removed[edge.key] == nil {
+				removed[edge.key] = &RepeatedLine{
+					Text:   strings.TrimSpace(lines[i][edge.index]),
+					Bottom: strings.HasPrefix(edge.key, "bottom"),
+				}
+			}
+			removed[edge.key].Pages++
+		}
+		if len(drop) == 0 {
+			continue
+		}
+
+		var kept []string
+		for j, line := range lines[i] {
+			if !drop[j] {
+				kept = append(kept, line)
+			}
+		}
+		pages[i] = strings.Join(kept, "\n")
+	}
+
+	report := make([]RepeatedLine, 0, len(removed))
+	for _, line := range removed {
+		report = append(report, *line)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Pages != report[j].Pages {
+			return report[i].Pages > report[j].Pages
+		}
+		return report[i].Text < report[j].Text
+	})
+
+	return strings.Join(pages, pageBreak), report
+}
+
+// pageEdges returns the first and last non-blank lines of a page, keyed by
+// their place, counted from the top or the bottom, and their text with
+// numbers masked. Running heads and feet keep their place from page to page,
+// while body text recurring near the edges of pages, such as penalty
+// statements, rarely does. Headings are never running heads.
+func pageEdges(lines []string) []edgeLine {
+	var content []int
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			content = append(content, i)
+		}
+	}
+
+	var edges []edgeLine
+	add := func(index int, position string) {
+		line := lines[index]
+		if strings.Contains(line, headingMark) {
+			return
+		}
+		key := strings.Join(strings.Fields(strings.ToLower(line)), " ")
+		key = digitsRe.ReplaceAllString(key, "#")
+		edges = append(edges, edgeLine{index: index, key: position + ":" + key})
+	}
+	for i := 0; i < len(content) && i < edgeLines; i++ {
+		add(content[i], fmt.Sprintf("top%d", i))
+	}
+	for i := 0; i < len(content) && i < edgeLines; i++ {
+		add(content[len(content)-1-i], fmt.Sprintf("bottom%d", i))
+	}
+	return edges
+}
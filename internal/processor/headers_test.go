@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveHeadersFooters(t *testing.T) {
+	const pages = 10
+	var in, want []string
+	for n := 1; n <= pages; n++ {
+		// Body text differs from page to page in its words, not only in numbers
+		var body []string
+		for line := 'a'; line <= 'f'; line++ {
+			body = append(body, fmt.Sprintf("Paragraph %c, sentence %c of the rules.", 'A'+n, line))
+		}
+		// A heading repeated at the top of a few pages, as when a rule
+		// continues, is not a running head
+		if n <= 4 {
+			body = append([]string{headingMark + "Rule 14 – Procedures for Ball"}, body...)
+		}
+		// Body text repeated near the foot of only a few pages is kept
+		if n <= 2 {
+			body = append(body, "Penalty for Breach of Rule 14.1: One Penalty Stroke.")
+		}
+
+		in = append(in, strings.Join(append(append([]string{"RULES OF GOLF  2023"}, body...),
+			fmt.Sprintf("Page %d", n)), "\n"))
+		want = append(want, strings.Join(body, "\n"))
+	}
+
+	text, removed := removeHeadersFooters(strings.Join(in, pageBreak))
+
+	if wantText := strings.Join(want, pageBreak); text != wantText {
+		t.Errorf("text =\n%q\nwant\n%q", text, wantText)
+	}
+	wantRemoved := []RepeatedLine{
+		{Text: "Page 1", Bottom: true, Pages: pages},
+		{Text: "RULES OF GOLF  2023", Pages: pages},
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("removed = %+v, want %+v", removed, wantRemoved)
+	}
+}
+
+func TestRemoveHeadersFootersFewPages(t *testing.T) {
+	// Lines must recur on at least repeatedLineMinPages pages
+	text := "Rules of Golf\nFirst page.\f\nRules of Golf\nSecond page."
+	text = strings.ReplaceAll(text, "\f", pageBreak)
+
+	got, removed := removeHeadersFooters(text)
+	if got != text || len(removed) != 0 {
+		t.Errorf("removeHeadersFooters() = %q, %+v; want the text unchanged", got, removed)
+	}
+}
@@ -0,0 +1,288 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// HeadingLevel is the level of a heading in the rule hierarchy
+type HeadingLevel int
+
+const (
+	HeadingRule       HeadingLevel = iota + 1 // e.g., "Rule 14 – Procedures for Ball"
+	HeadingSection                            // e.g., "14.3 Dropping Ball in Relief Area"
+	HeadingSubsection                         // e.g., "14.3c Ball Must Be Dropped in Relief Area"
+)
+
+// How a heading was detected
+const (
+	HeadingSourceFont  = "font"
+	HeadingSourceRegex = "regex"
+)
+
+const (
+	// headingMark starts the lines of headings detected from their font, so
+	// that the hierarchy patterns match them and nothing else
+	headingMark = "\x1e"
+
+	// headingScale is how much larger than body text, as a share of its
+	// size, a line must be set to be taken as a heading
+	headingScale = 1.1
+
+	// headingContinuation is the number of lines a heading may wrap onto
+	headingContinuation = 2
+)
+
+var (
+	// Numbered heading lines. Titles may be empty when they wrap onto the
+	// next line.
+	ruleHeadingRe       = regexp.MustCompile(`^Rule\s+(\d+)\b\s*[–—:.-]?\s*(.*)$`)
+	sectionHeadingRe    = regexp.MustCompile(`^(\d+\.\d+)(?:\s+(.*))?$`)
+	subsectionHeadingRe = regexp.MustCompile(`^(\d+\.\d+[a-z](?:\(\d+\))?)(?:\s+(.*))?$`)
+
+	// letterHeadingRe matches subsection headings numbered by letter alone,
+	// e.g. "c. Ball Must Be Dropped in Relief Area", under the current section
+	letterHeadingRe = regexp.MustCompile(`^([a-z])\.\s+(.+)$`)
+)
+
+// Heading is a rule, section or subsection heading found in a PDF
+type Heading struct {
+	Level  HeadingLevel
+	Number string // e.g., "Rule 14", "14.3" or "14.3c"
+	Title  string
+	Page   int
+	Source string // HeadingSourceFont or HeadingSourceRegex
+
+	offset int // Start of the heading line in the text it was found in
+}
+
+// String returns the heading as it appears in the Rules, e.g. "14.3 Dropping Ball in Relief Area"
+func (h Heading) String() string {
+	if h.Level == HeadingRule {
+		return fmt.Sprintf("%s – %s", h.Number, h.Title)
+	}
+	return fmt.Sprintf("%s %s", h.Number, h.Title)
+}
+
+// headingPatterns are the patterns for the rule, section and subsection
+// headings in extracted text
+type headingPatterns struct {
+	rule       *regexp.Regexp
+	section    *regexp.Regexp
+	subsection *regexp.Regexp
+	source     string
+}
+
+// patternsFor returns the heading patterns for text. Text with headings
+// detected from their font only matches those; otherwise any line
+// numbered like a heading does.
+func patternsFor(text string) headingPatterns {
+	prefix := ""
+	source := HeadingSourceRegex
+	if strings.Contains(text, headingMark) {
+		prefix = headingMark
+		source = HeadingSourceFont
+	}
+
+	return headingPatterns{
+		rule:       regexp.MustCompile(`(?m)^` + prefix + `(Rule\s+\d+)\s*[–—-]\s*(.+?)$`),
+		section:    regexp.MustCompile(`(?m)^` + prefix + `(\d+\.\d+)\s+(.+?)$`),
+		subsection: regexp.MustCompile(`(?m)^` + prefix + `(\d+\.\d+[a-z])\s+(.+?)$`),
+		source:     source,
+	}
+}
+
+// findHeadings returns the headings in text in the order they appear,
+// starting from the first rule heading, as extractRulesHierarchy sees them.
+// Pages are counted from firstPage, the page text starts on.
+func findHeadings(text string, firstPage int) []Heading {
+	patterns := patternsFor(text)
+
+	var headings []Heading
+	add := func(re *regexp.Regexp, level HeadingLevel) {
+		for _, match := range re.FindAllStringSubmatchIndex(text, -1) {
+			headings = append(headings, Heading{
+				Level:  level,
+				Number: strings.TrimSpace(text[match[2]:match[3]]),
+				Title:  strings.TrimSpace(text[match[4]:match[5]]),
+				Page:   pageAt(text, match[0], firstPage),
+				Source: patterns.source,
+				offset: match[0],
+			})
+		}
+	}
+	add(patterns.rule, HeadingRule)
+	add(patterns.section, HeadingSection)
+	add(patterns.subsection, HeadingSubsection)
+
+	sort.Slice(headings, func(i, j int) bool {
+		return headings[i].offset < headings[j].offset
+	})
+	for i, heading := range headings {
+		if heading.Level == HeadingRule {
+			return headings[i:]
+		}
+	}
+	return nil
+}
+
+// markHeadings finds the rule, section and subsection headings of pages by
+// their font: numbered lines set larger than body text or in bold. Each
+// heading is rewritten as a single marked line in the form the hierarchy
+// patterns expect, joining titles that wrap onto following lines; the
+// headings of Model Local Rules are marked as they are. Pages are
+// returned unchanged, and false, when they carry no font data or no heading
+// is found, leaving the hierarchy to the patterns alone.
+func markHeadings(pages []Page) ([]Page, bool) {
+	body := bodyFontSize(pages)
+	if body == 0 {
+		return pages, false
+	}
+
+	marked := make([]Page, len(pages))
+	found := false
+	section := ""
+	for n, page := range pages {
+		marked[n] = Page{Number: page.Number}
+		for i := 0; i < len(page.Lines); i++ {
+			line := page.Lines[i]
+			if !isHeadingLine(line, body) {
+				marked[n].Lines = append(marked[n].Lines, line)
+				continue
+			}
+
+			heading, ok := parseHeading(line.Text(), section)
+			if !ok {
+				// Model Local Rules are headed by their identifier, which
+				// is only a heading when set like one
+				if modelLocalRuleHeadingRe.MatchString(line.Text()) {
+					line = markLine(line, line.Text(), line.Y)
+				}
+				marked[n].Lines = append(marked[n].Lines, line)
+				continue
+			}
+
+			// Join the rest of a wrapped title, set in the same style
+			last := line
+			for wrapped := 0; wrapped < headingContinuation && i+1 < len(page.Lines); wrapped++ {
+				next := page.Lines[i+1]
+				if !sameStyle(last, next) || last.Y-next.Y > paragraphGap*last.FontSize() {
+					break
+				}
+				if _, numbered := parseHeading(next.Text(), section); numbered ||
+					modelLocalRuleHeadingRe.MatchString(next.Text()) {
+					break
+				}
+				heading.Title = strings.TrimSpace(heading.Title + " " + next.Text())
+				last = next
+				i++
+			}
+			if heading.Title == "" {
+				marked[n].Lines = append(marked[n].Lines, line)
+				continue
+			}
+
+			switch heading.Level {
+			case HeadingRule:
+				section = ""
+			case HeadingSection:
+				section = heading.Number
+			}
+
+			found = true
+			marked[n].Lines = append(marked[n].Lines, markLine(line, heading.String(), last.Y))
+		}
+	}
+
+	if !found {
+		return pages, false
+	}
+	return marked, true
+}
+
+// markLine returns a heading line of text, set in the style of line at
+// baseline y, starting with the heading mark
+func markLine(line Line, text string, y float64) Line {
+	return Line{
+		Runs: []TextRun{{
+			Text:     headingMark + text,
+			Font:     line.Runs[0].Font,
+			FontSize: line.FontSize(),
+			Bold:     line.Runs[0].Bold,
+			X:        line.Runs[0].X,
+			Y:        y,
+		}},
+		Y: y,
+	}
+}
+
+// parseHeading reads a numbered heading line. Headings numbered by letter
+// alone belong to section, and are not headings outside of one.
+func parseHeading(text, section string) (Heading, bool) {
+	if match := ruleHeadingRe.FindStringSubmatch(text); match != nil {
+		return Heading{Level: HeadingRule, Number: "Rule " + match[1], Title: match[2]}, true
+	}
+	if match := subsectionHeadingRe.FindStringSubmatch(text); match != nil {
+		return Heading{Level: HeadingSubsection, Number: match[1], Title: match[2]}, true
+	}
+	if match := sectionHeadingRe.FindStringSubmatch(text); match != nil {
+		return Heading{Level: HeadingSection, Number: match[1], Title: match[2]}, true
+	}
+	if match := letterHeadingRe.FindStringSubmatch(text); match != nil && section != "" {
+		return Heading{Level: HeadingSubsection, Number: section + match[1], Title: match[2]}, true
+	}
+	return Heading{}, false
+}
+
+// bodyFontSize returns the font size most of the text of pages is set in,
+// or 0 if they carry no font sizes
+func bodyFontSize(pages []Page) float64 {
+	chars := make(map[float64]int)
+	for _, page := range pages {
+		for _, line := range page.Lines {
+			for _, run := range line.Runs {
+				if run.FontSize > 0 {
+					chars[math.Round(run.FontSize*2)/2] += utf8.RuneCountInString(run.Text)
+				}
+			}
+		}
+	}
+
+	size, most := 0.0, 0
+	for s, count := range chars {
+		if count > most || (count == most && s < size) {
+			size, most = s, count
+		}
+	}
+	return size
+}
+
+// isHeadingLine reports whether a line is set like a heading: larger than
+// body text, or mostly in bold
+func isHeadingLine(line Line, body float64) bool {
+	if line.FontSize() >= body*headingScale {
+		return true
+	}
+
+	bold, total := 0, 0
+	for _, run := range line.Runs {
+		n := utf8.RuneCountInString(strings.TrimSpace(run.Text))
+		total += n
+		if run.Bold {
+			bold += n
+		}
+	}
+	return total > 0 && bold*2 > total
+}
+
+// sameStyle reports whether two lines are set in the same size and weight
+func sameStyle(a, b Line) bool {
+	if len(a.Runs) == 0 || len(b.Runs) == 0 {
+		return false
+	}
+	return math.Abs(a.FontSize()-b.FontSize()) < 0.5 && a.Runs[0].Bold == b.Runs[0].Bold
+}
@@ -0,0 +1,76 @@
+package processor
+
+import "testing"
+
+// styledLine builds a line of runs set in size at baseline y
+func styledLine(y, size float64, runs ...TextRun) Line {
+	for i := range runs {
+		runs[i].FontSize = size
+		runs[i].Y = y
+	}
+	return Line{Runs: runs, Y: y}
+}
+
+func regular(text string) TextRun {
+	return TextRun{Text: text, Font: "Helvetica"}
+}
+
+func bold(text string) TextRun {
+	return TextRun{Text: text, Font: "Helvetica-Bold", Bold: true}
+}
+
+func TestMarkHeadings(t *testing.T) {
+	pages := []Page{{Number: 1, Lines: []Line{
+		styledLine(750, 14, regular("Rule 14 – Procedures for Ball: Marking,")),
+		styledLine(734, 14, regular("Lifting and Cleaning")),
+		styledLine(720, 10, regular("A ball to be lifted under the Rules must be marked before it is lifted.")),
+		styledLine(706, 10, bold("Penalty for Breach of Rule 14.1: One Penalty Stroke.")),
+		styledLine(692, 10, regular("14.2 applies when a lifted ball is to be replaced on its spot.")),
+		styledLine(678, 10, bold("14.2 "), regular("is the rule for replacing a ball after it was lifted or moved.")),
+		styledLine(664, 10, bold("14.3 Dropping Ball in Relief Area")),
+		styledLine(650, 10, regular("The ball must be dropped from knee height and come to rest in the relief area.")),
+	}}}
+
+	marked, found := markHeadings(pages)
+	if !found {
+		t.Fatal("markHeadings() found no headings")
+	}
+
+	want := headingMark + "Rule 14 – Procedures for Ball: Marking, Lifting and Cleaning\n" +
+		"A ball to be lifted under the Rules must be marked before it is lifted.\n" +
+		"Penalty for Breach of Rule 14.1: One Penalty Stroke.\n" +
+		"14.2 applies when a lifted ball is to be replaced on its spot.\n" +
+		"14.2 is the rule for replacing a ball after it was lifted or moved.\n" +
+		headingMark + "14.3 Dropping Ball in Relief Area\n" +
+		"The ball must be dropped from knee height and come to rest in the relief area."
+	if got := marked[0].Text(); got != want {
+		t.Errorf("marked text =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMarkHeadingsWithoutHeadings(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []Line
+	}{
+		{"no font data", []Line{
+			styledLine(750, 0, regular("Rule 14 – Procedures for Ball")),
+			styledLine(730, 0, regular("A ball to be lifted must be marked.")),
+		}},
+		{"no numbered heading", []Line{
+			styledLine(750, 14, regular("Introduction")),
+			styledLine(730, 10, bold("Penalty for Breach of Rule 14.1: One Penalty Stroke.")),
+			styledLine(716, 10, regular("A ball to be lifted must be marked.")),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := []Page{{Number: 1, Lines: tt.lines}}
+			marked, found := markHeadings(pages)
+			if found || marked[0].Text() != pages[0].Text() {
+				t.Errorf("markHeadings() = %q, %v; want the page unchanged", marked[0].Text(), found)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// lineTolerance is how far apart, as a share of the font size, the
+	// baselines of two pieces of text may be and still be on one line
+	lineTolerance = 0.4
+
+	// wordGap is the horizontal gap, as a share of the font size, above
+	// which two pieces of text on a line are separated by a space
+	wordGap = 0.15
+
+	// paragraphGap is the vertical gap between lines, as a share of the font
+	// size, above which they are treated as separate paragraphs
+	paragraphGap = 1.8
+)
+
+// TextRun is a piece of a line set in a single font and size
+type TextRun struct {
+	Text     string
+	Font     string  // Base font name (e.g., "Helvetica-Bold")
+	FontSize float64 // In points
+	Bold     bool
+	X, Y     float64 // Start of the baseline, in points from the bottom left of the page
+	Width    float64
+}
+
+// Line is a line of text on a page, made of runs from left to right
+type Line struct {
+	Runs []TextRun
+	Y    float64 // Baseline, in points from the bottom of the page
+}
+
+// Text returns the text of the line
+func (l Line) Text() string {
+	var sb strings.Builder
+	for _, run := range l.Runs {
+		sb.WriteString(run.Text)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// FontSize returns the largest font size used on the line
+func (l Line) FontSize() float64 {
+	size := 0.0
+	for _, run := range l.Runs {
+		size = math.Max(size, run.FontSize)
+	}
+	return size
+}
+
+// Page is the text of one page of a PDF, laid out as lines
+type Page struct {
+	Number int    // Page number in the PDF, starting at 1
+	Lines  []Line // From top to bottom
+}
+
+// Text returns the lines of the page, with a blank line between paragraphs
+func (p Page) Text() string {
+	var sb strings.Builder
+	for i, line := range p.Lines {
+		if i > 0 {
+			sb.WriteString("\n")
+			previous := p.Lines[i-1]
+			if previous.Y-line.Y > paragraphGap*math.Max(previous.FontSize(), line.FontSize()) {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString(line.Text())
+	}
+	return sb.String()
+}
+
+// ExtractPages reads the text of every page of a PDF file, keeping the font,
+// size and position of each run of text. Pages without text are returned
+// with no lines, so that page numbers match the PDF.
+func (p *PDFProcessor) ExtractPages(filePath string) ([]Page, error) {
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	pages := make([]Page, 0, r.NumPage())
+	for number := 1; number <= r.NumPage(); number++ {
+		texts, err := pageContent(r.Page(number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", number, err)
+		}
+		pages = append(pages, Page{Number: number, Lines: layoutLines(texts)})
+	}
+
+	return pages, nil
+}
+
+// pageContent returns the pieces of text drawn on a page. The PDF reader
+// panics on malformed content streams, which is reported as an error.
+func pageContent(page pdf.Page) (texts []pdf.Text, err error) {
+	if page.V.IsNull() {
+		return nil, nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed content: %v", r)
+		}
+	}()
+	return page.Content().Text, nil
+}
+
+// layoutLines groups pieces of text into lines from top to bottom, and the
+// pieces of each line into runs of the same font from left to right
+func layoutLines(texts []pdf.Text) []Line {
+	var lines []Line
+	for _, text := range texts {
+		if text.S == "" {
+			continue
+		}
+
+		// Content streams are mostly in reading order, so the line is
+		// usually the last one
+		index := -1
+		for i := len(lines) - 1; i >= 0; i-- {
+			if math.Abs(lines[i].Y-text.Y) <= lineTolerance*math.Max(text.FontSize, 1) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			lines = append(lines, Line{Y: text.Y})
+			index = len(lines) - 1
+		}
+		lines[index].Runs = append(lines[index].Runs, TextRun{
+			Text:     text.S,
+			Font:     text.Font,
+			FontSize: text.FontSize,
+			Bold:     isBoldFont(text.Font),
+			X:        text.X,
+			Y:        text.Y,
+			Width:    text.W,
+		})
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Y > lines[j].Y
+	})
+	for i := range lines {
+		lines[i].Runs = mergeRuns(lines[i].Runs)
+	}
+
+	return lines
+}
+
+// mergeRuns orders the pieces of a line from left to right and joins
+// neighbouring pieces in the same font, inserting spaces at word gaps
+func mergeRuns(pieces []TextRun) []TextRun {
+	sort.SliceStable(pieces, func(i, j int) bool {
+		return pieces[i].X < pieces[j].X
+	})
+
+	var runs []TextRun
+	for _, piece := range pieces {
+		if len(runs) == 0 {
+			runs = append(runs, piece)
+			continue
+		}
+
+		last := &runs[len(runs)-1]
+		gap := piece.X - (last.X + last.Width)
+		if gap > wordGap*math.Max(piece.FontSize, 1) &&
+			!strings.HasSuffix(last.Text, " ") && !strings.HasPrefix(piece.Text, " ") {
+			last.Text += " "
+		}
+
+		if piece.Font == last.Font && piece.FontSize == last.FontSize {
+			last.Text += piece.Text
+			last.Width = piece.X + piece.Width - last.X
+			continue
+		}
+		runs = append(runs, piece)
+	}
+
+	return runs
+}
+
+// isBoldFont reports whether a font name denotes a bold weight
+func isBoldFont(font string) bool {
+	font = strings.ToLower(font)
+	for _, weight := range []string{"bold", "black", "heavy", "semibold", "demi"} {
+		if strings.Contains(font, weight) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageBreak separates the pages of extracted text. It sits on a line of its
+// own so that patterns anchored to the start of a line still match at the
+// top of a page.
+const pageBreak = "\n\f\n"
+
+// joinPages joins the text of pages, separated by page breaks
+func joinPages(pages []Page) string {
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = page.Text()
+	}
+	return strings.Join(texts, pageBreak)
+}
+
+// pageAt returns the page the given offset in text is on, text starting on firstPage
+func pageAt(text string, offset, firstPage int) int {
+	return firstPage + strings.Count(text[:offset], "\f")
+}
+
+// pageSpan returns the first and last page of text[start:end], ignoring
+// page breaks at its end
+func pageSpan(text string, start, end, firstPage int) (int, int) {
+	first := pageAt(text, start, firstPage)
+	return first, first + strings.Count(strings.TrimRight(text[start:end], " \t\n\f"), "\f")
+}
+
+// endPage returns the last page of a span for Metadata.EndPage, which is
+// only set for spans crossing pages
+func endPage(first, last int) int {
+	if last > first {
+		return last
+	}
+	return 0
+}
+
+// stripMarkers removes the page breaks and heading marks from text stored in a chunk
+func stripMarkers(text string) string {
+	text = strings.ReplaceAll(text, headingMark, "")
+	text = strings.ReplaceAll(text, pageBreak, "\n")
+	return strings.ReplaceAll(text, "\f", "\n")
+}
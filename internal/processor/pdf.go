@@ -1,16 +1,15 @@
 package processor
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strings"
 	_ "unicode"
 
 	"golf-rules-rag/internal/models"
-
-	"github.com/ledongthuc/pdf"
 )
 
 const (
@@ -24,6 +23,10 @@ const (
 type PDFProcessor struct {
 	ChunkSize    int
 	ChunkOverlap int
+
+	// RemovedLines are the running heads and feet removed from the pages of
+	// the last PDF processed
+	RemovedLines []RepeatedLine
 }
 
 // NewPDFProcessor creates a new PDF processor
@@ -41,26 +44,28 @@ func NewPDFProcessor(chunkSize, chunkOverlap int) *PDFProcessor {
 	}
 }
 
-// ExtractText extracts text from a PDF file
+// ExtractText extracts text from a PDF file, page by page. Pages are
+// separated by page breaks so that positions in the text map to pages, and
+// headings recognised by their font are marked for extractRulesHierarchy.
 func (p *PDFProcessor) ExtractText(filePath string) (string, error) {
-	f, r, err := pdf.Open(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open PDF: %w", err)
-	}
-	defer f.Close()
-
-	var buf bytes.Buffer
-	b, err := r.GetPlainText()
+	pages, err := p.ExtractPages(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract plain text: %w", err)
+		return "", err
 	}
+	pages, _ = markHeadings(pages)
+	return joinPages(pages), nil
+}
 
-	_, err = buf.ReadFrom(b)
+// ExtractOutline returns the rule, section and subsection headings of a PDF
+// file in order, as they are used to build the rule hierarchy
+func (p *PDFProcessor) ExtractOutline(filePath string) ([]Heading, error) {
+	text, err := p.ExtractText(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read text: %w", err)
+		return nil, fmt.Errorf("failed to extract text: %w", err)
 	}
 
-	return buf.String(), nil
+	ruleText, _, _ := p.extractDocumentSections(p.preprocessGolfRules(text))
+	return findHeadings(ruleText, 1), nil
 }
 
 // ProcessPDF processes a PDF file and returns optimized chunks for golf rules
@@ -73,17 +78,21 @@ func (p *PDFProcessor) ProcessPDF(ctx context.Context, filePath string) ([]model
 	// Preprocess text for golf-specific content
 	text = p.preprocessGolfRules(text)
 
+	// Take out the interpretations and Model Local Rules of the Official
+	// Guide, so they are not mistaken for rule text
+	text, guideChunks := p.extractOfficialGuide(text, 1)
+
 	// Extract different document sections
 	ruleText, definitionsText, indexText := p.extractDocumentSections(text)
 
 	// Process rules
-	ruleHierarchy := p.extractRulesHierarchy(ruleText)
+	ruleHierarchy := p.extractRulesHierarchy(ruleText, 1)
 
-	// Process definitions
-	definitionChunks := p.processDefinitions(definitionsText)
+	// Process definitions, which start on the page the rules end on
+	definitionChunks := p.processDefinitions(definitionsText, pageAt(text, len(ruleText), 1))
 
 	// Process index
-	indexEntries := p.processIndex(indexText)
+	indexEntries := p.processIndex(strings.ReplaceAll(indexText, headingMark, ""))
 
 	// Apply index terms to rule hierarchy
 	p.applyIndexTermsToRules(ruleHierarchy, indexEntries)
@@ -94,16 +103,36 @@ func (p *PDFProcessor) ProcessPDF(ctx context.Context, filePath string) ([]model
 	// Add definition chunks
 	chunks = append(chunks, definitionChunks...)
 
+	// Add interpretations and Model Local Rules, with the titles of the
+	// rules interpretations belong to
+	for i := range guideChunks {
+		if rule, ok := ruleHierarchy[guideChunks[i].Metadata.Section]; ok {
+			guideChunks[i].Metadata.Title = rule.Title
+		}
+	}
+	chunks = append(chunks, guideChunks...)
+
 	// Extract cross-references and update chunks
 	p.extractCrossReferences(chunks)
 
+	// Record penalty statements as structured metadata
+	p.extractPenalties(chunks)
+
+	// Tag chunks that only apply to match play or to stroke play
+	p.tagPlayFormats(chunks, ruleHierarchy)
+
+	// Hash chunk content so unchanged chunks can be skipped when re-indexing
+	for i := range chunks {
+		chunks[i].ContentHash = ContentHash(chunks[i].Content)
+	}
+
 	return chunks, nil
 }
 
 // preprocessGolfRules applies golf-specific preprocessing to the text
 func (p *PDFProcessor) preprocessGolfRules(text string) string {
-	// Remove headers and footers
-	text = p.removeHeadersFooters(text)
+	// Remove running heads and feet
+	text, p.RemovedLines = removeHeadersFooters(text)
 
 	// Normalize whitespace
 	text = p.normalizeWhitespace(text)
@@ -120,71 +149,29 @@ func (p *PDFProcessor) preprocessGolfRules(text string) string {
 	return text
 }
 
-// removeHeadersFooters removes headers and footers from the text
-func (p *PDFProcessor) removeHeadersFooters(text string) string {
-	// Split text by page breaks
-	pageRe := regexp.MustCompile(`\f`)
-	pages := pageRe.Split(text, -1)
-
-	var cleanedPages []string
-
-	for _, page := range pages {
-		lines := strings.Split(page, "\n")
-
-		// Skip empty pages
-		if len(lines) < 3 {
-			continue
-		}
-
-		// Remove header (first 1-2 lines) if it looks like a header
-		headerEnd := 0
-		for i := 0; i < min(3, len(lines)); i++ {
-			line := strings.TrimSpace(lines[i])
-			if len(line) < 50 && (strings.Contains(line, "Rules of Golf") ||
-				strings.Contains(line, "Page") || strings.Contains(line, "Contents")) {
-				headerEnd = i + 1
-			}
-		}
-
-		// Remove footer (last 1-3 lines) if it looks like a footer
-		footerStart := len(lines)
-		for i := len(lines) - 1; i >= max(0, len(lines)-4); i-- {
-			line := strings.TrimSpace(lines[i])
-			if len(line) < 50 && (strings.Contains(line, "©") ||
-				strings.Contains(line, "Page") || strings.Contains(line, "R&A") ||
-				strings.Contains(line, "USGA")) {
-				footerStart = i
-			}
-		}
-
-		// Extract the content between header and footer
-		if headerEnd < footerStart {
-			cleanedPages = append(cleanedPages, strings.Join(lines[headerEnd:footerStart], "\n"))
-		} else {
-			cleanedPages = append(cleanedPages, page) // Fallback if detection fails
-		}
-	}
-
-	return strings.Join(cleanedPages, "\n")
-}
-
-// normalizeWhitespace normalizes whitespace in the text
+// normalizeWhitespace normalizes whitespace in the text, keeping the line
+// and page breaks the rule patterns and page tracking rely on
 func (p *PDFProcessor) normalizeWhitespace(text string) string {
-	// Replace multiple spaces with a single space
-	spaceRe := regexp.MustCompile(`\s+`)
+	// Replace runs of spaces and tabs with a single space
+	spaceRe := regexp.MustCompile(`[ \t\r\v]+`)
 	text = spaceRe.ReplaceAllString(text, " ")
 
+	// Drop spaces around line breaks
+	lineEndRe := regexp.MustCompile(` ?\n ?`)
+	text = lineEndRe.ReplaceAllString(text, "\n")
+
 	// Ensure paragraphs are separated by double newlines
-	paraSepRe := regexp.MustCompile(`\n\s*\n+`)
+	paraSepRe := regexp.MustCompile(`\n\n\n+`)
 	text = paraSepRe.ReplaceAllString(text, "\n\n")
 
-	return strings.TrimSpace(text)
+	// Trim without removing page breaks at either end, which count pages
+	return strings.Trim(text, " \n")
 }
 
 // normalizeRuleReferences standardizes rule references throughout the text
 func (p *PDFProcessor) normalizeRuleReferences(text string) string {
 	// Normalize rule references like "Rule 14.3" to a standard format
-	ruleRefRe := regexp.MustCompile(`Rule\s+(\d+)([a-z])?(\.\d+)?([a-z])?`)
+	ruleRefRe := regexp.MustCompile(`Rule[^\S\f]+(\d+)([a-z])?(\.\d+)?([a-z])?`)
 	text = ruleRefRe.ReplaceAllString(text, "Rule $1$2$3$4")
 
 	// Fix common OCR errors in rule numbers
@@ -215,7 +202,7 @@ func (p *PDFProcessor) expandGolfAbbreviations(text string) string {
 // handleDiagramReferences preserves diagram references
 func (p *PDFProcessor) handleDiagramReferences(text string) string {
 	// Identify and mark diagram references for preservation
-	diagramRe := regexp.MustCompile(`DIAGRAM\s+(\d+(\.\d+)?[a-z]?)`)
+	diagramRe := regexp.MustCompile(`DIAGRAM[^\S\f]+(\d+(\.\d+)?[a-z]?)`)
 	text = diagramRe.ReplaceAllString(text, "[DIAGRAM_REF:$1]")
 
 	return text
@@ -254,117 +241,117 @@ func (p *PDFProcessor) extractDocumentSections(text string) (string, string, str
 	return ruleText, definitionsText, indexText
 }
 
-// extractRulesHierarchy builds the complete rule hierarchy
-func (p *PDFProcessor) extractRulesHierarchy(text string) map[string]models.GolfRuleHierarchy {
+// extractRulesHierarchy builds the complete rule hierarchy. Rules and their
+// parts may span pages; the pages they start and end on are counted from
+// firstPage, the page text starts on. Only headings marked by their font
+// are used when the text has any, and otherwise lines numbered like headings.
+func (p *PDFProcessor) extractRulesHierarchy(text string, firstPage int) map[string]models.GolfRuleHierarchy {
 	hierarchy := make(map[string]models.GolfRuleHierarchy)
 
 	// Patterns for rules hierarchy
-	mainRuleRe := regexp.MustCompile(`(?m)^(Rule\s+\d+)\s*[–—-]\s*(.+?)$`)
-	sectionRe := regexp.MustCompile(`(?m)^(\d+\.\d+)\s+(.+?)$`)
-	subsectionRe := regexp.MustCompile(`(?m)^(\d+\.\d+[a-z](?:\(\d+\))?)\s+(.+?)$`)
-
-	// Split text by page breaks to track page numbers
-	pageRe := regexp.MustCompile(`\f`)
-	pages := pageRe.Split(text, -1)
-
-	currentPageNum := 1
-	currentRule := ""
-	currentSection := ""
-
-	// Process each page
-	for _, page := range pages {
-		// Find main rules on this page
-		mainRuleMatches := mainRuleRe.FindAllStringSubmatchIndex(page, -1)
-
-		for i, match := range mainRuleMatches {
-			ruleStart := match[0]
-			ruleEnd := len(page)
-			if i < len(mainRuleMatches)-1 {
-				ruleEnd = mainRuleMatches[i+1][0]
-			}
+	patterns := patternsFor(text)
+	mainRuleRe := patterns.rule
+	sectionRe := patterns.section
+	subsectionRe := patterns.subsection
+
+	// Find main rules
+	mainRuleMatches := mainRuleRe.FindAllStringSubmatchIndex(text, -1)
+
+	for i, match := range mainRuleMatches {
+		ruleStart := match[0]
+		ruleEnd := len(text)
+		if i < len(mainRuleMatches)-1 {
+			ruleEnd = mainRuleMatches[i+1][0]
+		}
+
+		ruleText := text[ruleStart:ruleEnd]
+		ruleNum := strings.TrimSpace(text[match[2]:match[3]])
+		ruleTitle := strings.TrimSpace(text[match[4]:match[5]])
+		rulePage, ruleLastPage := pageSpan(text, ruleStart, ruleEnd, firstPage)
+
+		// Create rule entry
+		rule := models.GolfRuleHierarchy{
+			RuleNumber: ruleNum,
+			Title:      ruleTitle,
+			PageNumber: rulePage,
+			EndPage:    endPage(rulePage, ruleLastPage),
+			Sections:   make(map[string]models.RuleSection),
+			Path:       ruleNum,
+		}
+
+		// Find sections within this rule
+		sectionMatches := sectionRe.FindAllStringSubmatchIndex(ruleText, -1)
 
-			ruleText := page[ruleStart:ruleEnd]
-			ruleNum := strings.TrimSpace(page[match[2]:match[3]])
-			ruleTitle := strings.TrimSpace(page[match[4]:match[5]])
-
-			// Create rule entry
-			hierarchy[ruleNum] = models.GolfRuleHierarchy{
-				RuleNumber: ruleNum,
-				Title:      ruleTitle,
-				PageNumber: currentPageNum,
-				Sections:   make(map[string]models.RuleSection),
-				Path:       ruleNum,
+		for j, sectionMatch := range sectionMatches {
+			sectionStart := sectionMatch[0]
+			sectionEnd := len(ruleText)
+			if j < len(sectionMatches)-1 {
+				sectionEnd = sectionMatches[j+1][0]
 			}
 
-			currentRule = ruleNum
+			sectionText := ruleText[sectionStart:sectionEnd]
+			sectionNum := strings.TrimSpace(ruleText[sectionMatch[2]:sectionMatch[3]])
+			sectionTitle := strings.TrimSpace(ruleText[sectionMatch[4]:sectionMatch[5]])
+			sectionPage, sectionLastPage := pageSpan(ruleText, sectionStart, sectionEnd, rulePage)
+
+			section := models.RuleSection{
+				Number:      sectionNum,
+				Title:       sectionTitle,
+				PageNumber:  sectionPage,
+				EndPage:     endPage(sectionPage, sectionLastPage),
+				Subsections: make(map[string]models.RuleSubsection),
+				Content:     sectionText,
+				Path:        fmt.Sprintf("%s > %s", ruleNum, sectionNum),
+			}
 
-			// Find sections within this rule
-			sectionMatches := sectionRe.FindAllStringSubmatchIndex(ruleText, -1)
+			// Find subsections within this section
+			subsectionMatches := subsectionRe.FindAllStringSubmatchIndex(sectionText, -1)
 
-			for j, sectionMatch := range sectionMatches {
-				sectionStart := sectionMatch[0]
-				sectionEnd := len(ruleText)
-				if j < len(sectionMatches)-1 {
-					sectionEnd = sectionMatches[j+1][0]
+			for k, subsectionMatch := range subsectionMatches {
+				subsectionStart := subsectionMatch[0]
+				subsectionEnd := len(sectionText)
+				if k < len(subsectionMatches)-1 {
+					subsectionEnd = subsectionMatches[k+1][0]
 				}
 
-				sectionText := ruleText[sectionStart:sectionEnd]
-				sectionNum := strings.TrimSpace(ruleText[sectionMatch[2]:sectionMatch[3]])
-				sectionTitle := strings.TrimSpace(ruleText[sectionMatch[4]:sectionMatch[5]])
-
-				// Check if this rule exists in our hierarchy
-				if rule, exists := hierarchy[currentRule]; exists {
-					rule.Sections[sectionNum] = models.RuleSection{
-						Number:      sectionNum,
-						Title:       sectionTitle,
-						PageNumber:  currentPageNum,
-						Subsections: make(map[string]models.RuleSubsection),
-						Content:     sectionText,
-						Path:        fmt.Sprintf("%s > %s", currentRule, sectionNum),
-					}
-					hierarchy[currentRule] = rule
-
-					currentSection = sectionNum
-
-					// Find subsections within this section
-					subsectionMatches := subsectionRe.FindAllStringSubmatchIndex(sectionText, -1)
-
-					for k, subsectionMatch := range subsectionMatches {
-						subsectionStart := subsectionMatch[0]
-						subsectionEnd := len(sectionText)
-						if k < len(subsectionMatches)-1 {
-							subsectionEnd = subsectionMatches[k+1][0]
-						}
-
-						subsectionText := sectionText[subsectionStart:subsectionEnd]
-						subsectionNum := strings.TrimSpace(sectionText[subsectionMatch[2]:subsectionMatch[3]])
-						subsectionTitle := strings.TrimSpace(sectionText[subsectionMatch[4]:subsectionMatch[5]])
-
-						// Check if this section exists in our rule
-						if section, exists := rule.Sections[currentSection]; exists {
-							section.Subsections[subsectionNum] = models.RuleSubsection{
-								Number:     subsectionNum,
-								Title:      subsectionTitle,
-								Content:    subsectionText,
-								PageNumber: currentPageNum,
-								Path:       fmt.Sprintf("%s > %s > %s", currentRule, currentSection, subsectionNum),
-							}
-							rule.Sections[currentSection] = section
-							hierarchy[currentRule] = rule
-						}
-					}
+				subsectionText := sectionText[subsectionStart:subsectionEnd]
+				subsectionNum := strings.TrimSpace(sectionText[subsectionMatch[2]:subsectionMatch[3]])
+				subsectionTitle := strings.TrimSpace(sectionText[subsectionMatch[4]:subsectionMatch[5]])
+				subsectionPage, subsectionLastPage := pageSpan(sectionText, subsectionStart, subsectionEnd, sectionPage)
+
+				subsection := models.RuleSubsection{
+					Number:     subsectionNum,
+					Title:      subsectionTitle,
+					Content:    subsectionText,
+					PageNumber: subsectionPage,
+					EndPage:    endPage(subsectionPage, subsectionLastPage),
+					Path:       fmt.Sprintf("%s > %s > %s", ruleNum, sectionNum, subsectionNum),
 				}
+				subsection.Clauses = p.extractClauses(subsectionText, subsectionNum, subsection.Path, subsectionPage)
+				section.Subsections[subsectionNum] = subsection
 			}
+
+			// Exceptions and notes of the section itself come before its subsections
+			sectionOwn := len(sectionText)
+			if len(subsectionMatches) > 0 {
+				sectionOwn = subsectionMatches[0][0]
+			}
+			for label, clause := range p.extractClauses(sectionText[:sectionOwn], sectionNum, section.Path, sectionPage) {
+				section.Subsections[label] = clause
+			}
+
+			rule.Sections[sectionNum] = section
 		}
 
-		currentPageNum++
+		hierarchy[ruleNum] = rule
 	}
 
 	return hierarchy
 }
 
-// processDefinitions extracts and chunks the definitions section
-func (p *PDFProcessor) processDefinitions(text string) []models.TextChunk {
+// processDefinitions extracts and chunks the definitions section, which
+// starts on firstPage
+func (p *PDFProcessor) processDefinitions(text string, firstPage int) []models.TextChunk {
 	if text == "" {
 		return nil
 	}
@@ -387,16 +374,19 @@ func (p *PDFProcessor) processDefinitions(text string) []models.TextChunk {
 
 		defText := text[defStart:defEnd]
 		defTerm := strings.TrimSpace(text[match[2]:match[3]])
+		defPage, defLastPage := pageSpan(text, defStart, defEnd, firstPage)
 
 		// Create a chunk for this definition
 		chunks = append(chunks, models.TextChunk{
 			ID:      chunkID,
-			Content: defText,
+			Content: stripMarkers(defText),
 			Metadata: models.Metadata{
-				Section:   "Definitions",
-				Title:     defTerm,
-				ChunkType: "definition",
-				Hierarchy: fmt.Sprintf("Definitions > %s", defTerm),
+				PageNumber: defPage,
+				EndPage:    endPage(defPage, defLastPage),
+				Section:    "Definitions",
+				Title:      defTerm,
+				ChunkType:  models.ChunkTypeDefinition,
+				Hierarchy:  fmt.Sprintf("Definitions > %s", defTerm),
 			},
 		})
 
@@ -480,6 +470,7 @@ func (p *PDFProcessor) createRuleBasedChunks(ruleHierarchy map[string]models.Gol
 			Content: ruleIntro,
 			Metadata: models.Metadata{
 				PageNumber: rule.PageNumber,
+				EndPage:    rule.EndPage,
 				Section:    ruleNum,
 				Title:      rule.Title,
 				Hierarchy:  rule.Path,
@@ -510,9 +501,10 @@ func (p *PDFProcessor) createRuleBasedChunks(ruleHierarchy map[string]models.Gol
 				// Add section as a single chunk
 				chunks = append(chunks, models.TextChunk{
 					ID:      chunkID,
-					Content: section.Content,
+					Content: stripMarkers(section.Content),
 					Metadata: models.Metadata{
 						PageNumber:  section.PageNumber,
+						EndPage:     section.EndPage,
 						Section:     ruleNum,
 						Title:       rule.Title,
 						Subsection:  sectionNum,
@@ -526,32 +518,17 @@ func (p *PDFProcessor) createRuleBasedChunks(ruleHierarchy map[string]models.Gol
 				chunkID++
 			}
 
-			// Add subsections separately for better retrieval
-			for subsectionNum, subsection := range section.Subsections {
-				chunks = append(chunks, models.TextChunk{
-					ID:      chunkID,
-					Content: subsection.Content,
-					Metadata: models.Metadata{
-						PageNumber:  subsection.PageNumber,
-						Section:     ruleNum,
-						Title:       rule.Title,
-						Subsection:  subsectionNum,
-						SubsecTitle: subsection.Title,
-						Hierarchy:   subsection.Path,
-						ParentRule:  ruleNum,
-						ChunkType:   "subsection",
-					},
-					IndexTerms: rule.IndexTerms,
-				})
-				chunkID++
-			}
+			// Add subsections separately for better retrieval, along with their
+			// clauses, exceptions and notes at every depth
+			chunks, chunkID = appendClauseChunks(chunks, section.Subsections, ruleNum, rule, chunkID)
 		}
 	}
 
 	return chunks
 }
 
-// splitSectionIntoChunks splits a large section into multiple chunks
+// splitSectionIntoChunks splits a large section into multiple chunks. The
+// section starts on pageNum, and each chunk records the pages it spans.
 func (p *PDFProcessor) splitSectionIntoChunks(content string, startID int,
 	ruleNum, ruleTitle, sectionNum, sectionTitle, path string, pageNum int,
 	indexTerms []string) []models.TextChunk {
@@ -562,30 +539,40 @@ func (p *PDFProcessor) splitSectionIntoChunks(content string, startID int,
 	// Split into paragraphs
 	paragraphs := strings.Split(content, "\n\n")
 
+	// Offsets in content of the current chunk and of the paragraphs in it
+	chunkStart, chunkEnd, lastParaStart, offset := 0, 0, 0, 0
+
+	addChunk := func(text string) {
+		first, last := pageSpan(content, chunkStart, chunkEnd, pageNum)
+		chunks = append(chunks, models.TextChunk{
+			ID:      chunkID,
+			Content: stripMarkers(text),
+			Metadata: models.Metadata{
+				PageNumber:  first,
+				EndPage:     endPage(first, last),
+				Section:     ruleNum,
+				Title:       ruleTitle,
+				Subsection:  sectionNum,
+				SubsecTitle: sectionTitle,
+				Hierarchy:   path,
+				ParentRule:  ruleNum,
+				ChunkType:   "section",
+			},
+			IndexTerms: indexTerms,
+		})
+		chunkID++
+	}
+
 	var currentChunk strings.Builder
 	for _, para := range paragraphs {
 		// If adding this paragraph would make the chunk too large
 		if currentChunk.Len()+len(para) > p.ChunkSize && currentChunk.Len() > MinChunkSize {
 			// Create a chunk with current content
-			chunks = append(chunks, models.TextChunk{
-				ID:      chunkID,
-				Content: currentChunk.String(),
-				Metadata: models.Metadata{
-					PageNumber:  pageNum,
-					Section:     ruleNum,
-					Title:       ruleTitle,
-					Subsection:  sectionNum,
-					SubsecTitle: sectionTitle,
-					Hierarchy:   path,
-					ParentRule:  ruleNum,
-					ChunkType:   "section",
-				},
-				IndexTerms: indexTerms,
-			})
-			chunkID++
+			addChunk(currentChunk.String())
 
 			// Reset the builder with overlap
 			currentChunk = strings.Builder{}
+			chunkStart = offset
 
 			// Include the last paragraph for overlap context
 			if len(chunks) > 0 && len(paragraphs) > 1 {
@@ -593,6 +580,7 @@ func (p *PDFProcessor) splitSectionIntoChunks(content string, startID int,
 				if len(lastPara) > 0 {
 					currentChunk.WriteString(lastPara)
 					currentChunk.WriteString("\n\n")
+					chunkStart = lastParaStart
 				}
 			}
 		}
@@ -602,25 +590,15 @@ func (p *PDFProcessor) splitSectionIntoChunks(content string, startID int,
 			currentChunk.WriteString("\n\n")
 		}
 		currentChunk.WriteString(para)
+
+		lastParaStart = offset
+		chunkEnd = offset + len(para)
+		offset = chunkEnd + len("\n\n")
 	}
 
 	// Add the final chunk if there's content left
 	if currentChunk.Len() > 0 {
-		chunks = append(chunks, models.TextChunk{
-			ID:      chunkID,
-			Content: currentChunk.String(),
-			Metadata: models.Metadata{
-				PageNumber:  pageNum,
-				Section:     ruleNum,
-				Title:       ruleTitle,
-				Subsection:  sectionNum,
-				SubsecTitle: sectionTitle,
-				Hierarchy:   path,
-				ParentRule:  ruleNum,
-				ChunkType:   "section",
-			},
-			IndexTerms: indexTerms,
-		})
+		addChunk(currentChunk.String())
 	}
 
 	return chunks
@@ -628,7 +606,7 @@ func (p *PDFProcessor) splitSectionIntoChunks(content string, startID int,
 
 // extractCrossReferences finds and assigns cross-references to each chunk
 func (p *PDFProcessor) extractCrossReferences(chunks []models.TextChunk) {
-	ruleRefPattern := regexp.MustCompile(`(Rule \d+(\.\d+[a-z]?)?)`)
+	ruleRefPattern := regexp.MustCompile(`(Rule \d+(\.\d+[a-z]?(\((\d+|[a-z])\))*)?)`)
 
 	for i, chunk := range chunks {
 		// Find all rule references in the chunk
@@ -651,6 +629,12 @@ func (p *PDFProcessor) extractCrossReferences(chunks []models.TextChunk) {
 	}
 }
 
+// ContentHash returns the hex-encoded SHA-256 of a chunk's content
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 // getLastParagraph extracts the last paragraph from text
 func getLastParagraph(text string) string {
 	paragraphs := strings.Split(text, "\n\n")
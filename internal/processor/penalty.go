@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+)
+
+// maxPenaltyText bounds the statement stored with a penalty
+const maxPenaltyText = 300
+
+var (
+	// breachPattern matches "Penalty for Breach of Rule 14.3: General Penalty"
+	// and its variants, capturing the rule and the penalty
+	breachPattern = regexp.MustCompile(`(?i)Penalty\s+for\s+(?:Breach(?:ing)?\s+of|Playing\s+from\s+a\s+Wrong\s+Place\s+in\s+Breach\s+of)\s+Rules?\s+` +
+		`(\d+(?:\.\d+[a-z]?(?:\(\d+\))*)?)[^:\n]*:\s*([^\n]+)`)
+
+	// penaltyPatterns classify penalty statements, most specific first
+	penaltyPatterns = []struct {
+		kind    string
+		pattern *regexp.Regexp
+	}{
+		{models.PenaltyDisqualification, regexp.MustCompile(`(?i)\bdisqualif(?:ied|ication)\b`)},
+		{models.PenaltyStrokeAndDistance, regexp.MustCompile(`(?i)\bstroke[\s-]+and[\s-]+distance\b`)},
+		{models.PenaltyGeneral, regexp.MustCompile(`(?i)\bgeneral\s+penalty\b`)},
+		{models.PenaltyLossOfHole, regexp.MustCompile(`(?i)\bloss\s+of\s+(?:the\s+)?hole\b`)},
+		{models.PenaltyTwoStroke, regexp.MustCompile(`(?i)\btwo[\s-]+(?:penalty\s+strokes|stroke\s+penalty)\b`)},
+		{models.PenaltyOneStroke, regexp.MustCompile(`(?i)\b(?:one[\s-]+(?:penalty\s+stroke|stroke\s+penalty)|penalty\s+of\s+one\s+stroke)\b`)},
+	}
+
+	// noPenaltyPattern matches statements that rule a penalty out
+	noPenaltyPattern = regexp.MustCompile(`(?i)\b(?:no|without|free\s+of)\s+penalty\b`)
+)
+
+// extractPenalties finds the penalty statements in each chunk and records
+// them as structured metadata. Explicit "Penalty for Breach of Rule" lines
+// name the rule they apply to; other statements are attributed to the part
+// of the rule the chunk holds.
+func (p *PDFProcessor) extractPenalties(chunks []models.TextChunk) {
+	for i := range chunks {
+		chunks[i].Penalties = detectPenalties(chunks[i].Content, chunkRule(&chunks[i]))
+	}
+}
+
+// detectPenalties returns the penalties stated in text, without duplicates.
+// rule is the rule statements without an explicit one are attributed to.
+func detectPenalties(text, rule string) []models.Penalty {
+	var penalties []models.Penalty
+	seen := make(map[string]bool)
+
+	add := func(penalty models.Penalty) {
+		key := penalty.Kind + "\x00" + penalty.Rule
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		penalties = append(penalties, penalty)
+	}
+
+	// Explicit breach statements come first and take precedence. They are
+	// blanked out so that the sentences below do not count them again.
+	remaining := []byte(text)
+	for _, match := range breachPattern.FindAllStringSubmatchIndex(text, -1) {
+		for j := match[0]; j < match[1]; j++ {
+			remaining[j] = ' '
+		}
+
+		kind := penaltyKind(text[match[4]:match[5]])
+		if kind == "" {
+			continue
+		}
+		add(models.Penalty{
+			Kind: kind,
+			Rule: "Rule " + text[match[2]:match[3]],
+			Text: trimStatement(text[match[0]:match[1]]),
+		})
+	}
+
+	for _, sentence := range splitSentences(string(remaining)) {
+		if noPenaltyPattern.MatchString(sentence) {
+			continue
+		}
+		kind := penaltyKind(sentence)
+		if kind == "" {
+			continue
+		}
+		add(models.Penalty{Kind: kind, Rule: rule, Text: trimStatement(sentence)})
+	}
+
+	return penalties
+}
+
+// penaltyKind classifies a penalty statement, returning "" if it states none
+func penaltyKind(statement string) string {
+	for _, p := range penaltyPatterns {
+		if p.pattern.MatchString(statement) {
+			return p.kind
+		}
+	}
+	return ""
+}
+
+// chunkRule returns the most specific rule a chunk holds, e.g. "Rule 14.3c",
+// or "" for chunks outside the numbered rules
+func chunkRule(chunk *models.TextChunk) string {
+	if chunk.Metadata.Subsection != "" {
+		return "Rule " + chunk.Metadata.Subsection
+	}
+	if strings.HasPrefix(chunk.Metadata.Section, "Rule ") {
+		return chunk.Metadata.Section
+	}
+	return ""
+}
+
+// splitSentences splits text at sentence ends and line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		end := text[i] == '\n' ||
+			(strings.IndexByte(".!?", text[i]) >= 0 && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n'))
+		if !end {
+			continue
+		}
+		if sentence := strings.TrimSpace(text[start : i+1]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+	if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// trimStatement collapses whitespace and bounds the length of a statement
+func trimStatement(statement string) string {
+	statement = strings.Join(strings.Fields(statement), " ")
+	if len(statement) > maxPenaltyText {
+		statement = strings.TrimSpace(statement[:maxPenaltyText]) + "..."
+	}
+	return statement
+}
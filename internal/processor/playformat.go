@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+
+	"golf-rules-rag/internal/models"
+)
+
+var (
+	matchPlayPattern  = regexp.MustCompile(`(?i)\bmatch[\s-]+play\b`)
+	strokePlayPattern = regexp.MustCompile(`(?i)\bstroke[\s-]+play\b`)
+)
+
+// tagPlayFormats records on each chunk the play format it is specific to,
+// taken from the titles of the headings it is under: the innermost naming
+// one format decides, e.g. "3.2 Match Play" for the parts of 3.2. A chunk
+// whose text merely mentions a format applies to both.
+func (p *PDFProcessor) tagPlayFormats(chunks []models.TextChunk, ruleHierarchy map[string]models.GolfRuleHierarchy) {
+	for i := range chunks {
+		metadata := &chunks[i].Metadata
+
+		titles := append([]string{metadata.Title}, pathTitles(ruleHierarchy, metadata.Hierarchy)...)
+		titles = append(titles, metadata.SubsecTitle)
+
+		metadata.PlayFormat = ""
+		for j := len(titles) - 1; j >= 0; j-- {
+			if format := playFormat(titles[j]); format != "" {
+				metadata.PlayFormat = format
+				break
+			}
+		}
+	}
+}
+
+// pathTitles returns the titles of the headings along a hierarchy path,
+// from the rule down, e.g. those of Rule 3, 3.2 and 3.2b for
+// "Rule 3 > 3.2 > 3.2b"
+func pathTitles(ruleHierarchy map[string]models.GolfRuleHierarchy, path string) []string {
+	parts := strings.Split(path, " > ")
+	rule, ok := ruleHierarchy[parts[0]]
+	if !ok {
+		return nil
+	}
+	titles := []string{rule.Title}
+	if len(parts) < 2 {
+		return titles
+	}
+
+	section, ok := rule.Sections[parts[1]]
+	if !ok {
+		return titles
+	}
+	titles = append(titles, section.Title)
+
+	// Subsections, then clauses, exceptions and notes at any depth
+	below := section.Subsections
+	for _, number := range parts[2:] {
+		part, ok := below[number]
+		if !ok {
+			break
+		}
+		titles = append(titles, part.Title)
+		below = part.Clauses
+	}
+	return titles
+}
+
+// playFormat returns the play format text mentions, or "" when it mentions
+// neither or both
+func playFormat(text string) string {
+	match := matchPlayPattern.MatchString(text)
+	stroke := strokePlayPattern.MatchString(text)
+
+	switch {
+	case match && !stroke:
+		return models.PlayFormatMatch
+	case stroke && !match:
+		return models.PlayFormatStroke
+	default:
+		return ""
+	}
+}
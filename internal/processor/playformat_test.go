@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"testing"
+
+	"golf-rules-rag/internal/models"
+)
+
+func TestTagPlayFormats(t *testing.T) {
+	ruleHierarchy := map[string]models.GolfRuleHierarchy{
+		"Rule 3": {RuleNumber: "Rule 3", Title: "The Competition", Sections: map[string]models.RuleSection{
+			"3.2": {Number: "3.2", Title: "Match Play", Subsections: map[string]models.RuleSubsection{
+				"3.2b": {Number: "3.2b", Title: "Concessions"},
+			}},
+			"3.3": {Number: "3.3", Title: "Stroke Play", Subsections: map[string]models.RuleSubsection{
+				"3.3b": {Number: "3.3b", Title: "Scoring in Stroke Play", Clauses: map[string]models.RuleSubsection{
+					"3.3b(1)": {Number: "3.3b(1)", Title: "Marker"},
+				}},
+			}},
+		}},
+		"Rule 11": {RuleNumber: "Rule 11", Title: "Ball in Motion Accidentally Hits Person", Sections: map[string]models.RuleSection{
+			"11.1": {Number: "11.1", Title: "Ball in Motion Accidentally Hits Person or Outside Influence"},
+		}},
+	}
+
+	tests := []struct {
+		name  string
+		chunk models.TextChunk
+		want  string
+	}{
+		{
+			name: "under a format section",
+			chunk: models.TextChunk{Content: "A player may concede a stroke.",
+				Metadata: models.Metadata{Title: "The Competition", SubsecTitle: "Concessions", Hierarchy: "Rule 3 > 3.2 > 3.2b"}},
+			want: models.PlayFormatMatch,
+		},
+		{
+			name: "clause under a format section",
+			chunk: models.TextChunk{Content: "The marker must certify the score.",
+				Metadata: models.Metadata{Title: "The Competition", SubsecTitle: "Marker", Hierarchy: "Rule 3 > 3.3 > 3.3b > 3.3b(1)"}},
+			want: models.PlayFormatStroke,
+		},
+		{
+			name: "section titled by format",
+			chunk: models.TextChunk{Content: "In match play the player and opponent compete.",
+				Metadata: models.Metadata{Title: "The Competition", SubsecTitle: "Match Play", Hierarchy: "Rule 3 > 3.2"}},
+			want: models.PlayFormatMatch,
+		},
+		{
+			name: "general rule mentioning a format",
+			chunk: models.TextChunk{Content: "There is no penalty. In stroke play, the stroke counts.",
+				Metadata: models.Metadata{Title: "Ball in Motion Accidentally Hits Person",
+					SubsecTitle: "Ball in Motion Accidentally Hits Person or Outside Influence", Hierarchy: "Rule 11 > 11.1"}},
+			want: "",
+		},
+		{
+			name: "definition of a format",
+			chunk: models.TextChunk{Content: "A form of play where a player plays directly against an opponent.",
+				Metadata: models.Metadata{Title: "Match Play", Hierarchy: "Definitions > Match Play"}},
+			want: models.PlayFormatMatch,
+		},
+	}
+
+	p := NewPDFProcessor(0, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := []models.TextChunk{tt.chunk}
+			p.tagPlayFormats(chunks, ruleHierarchy)
+			if got := chunks[0].Metadata.PlayFormat; got != tt.want {
+				t.Errorf("PlayFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package qa
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+// DefaultDefinitionLimit is the number of definitions added to the contexts of a query
+const DefaultDefinitionLimit = 3
+
+// expandDefinitions returns the definitions of the defined terms used in the
+// query and in the contexts, at most limit of them. Terms in the query come
+// first, then terms in the contexts by rank. Definitions already among the
+// contexts are left out.
+func expandDefinitions(ctx context.Context, store database.VectorStore, query string,
+	chunks []models.TextChunk, limit int) ([]models.TextChunk, error) {
+
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	terms, err := store.GetDefinitionTerms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list defined terms: %w", err)
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	matcher := newTermMatcher(terms)
+
+	seen := make(map[string]bool)
+	for _, chunk := range chunks {
+		if chunk.Metadata.ChunkType == models.ChunkTypeDefinition {
+			seen[strings.ToLower(chunk.Metadata.Title)] = true
+		}
+	}
+
+	var selected []string
+	add := func(text string) {
+		for _, term := range matcher.find(text) {
+			if len(selected) == limit {
+				return
+			}
+			if key := strings.ToLower(term); !seen[key] {
+				seen[key] = true
+				selected = append(selected, term)
+			}
+		}
+	}
+	add(query)
+	for _, chunk := range chunks {
+		if chunk.Metadata.ChunkType != models.ChunkTypeDefinition {
+			add(chunk.Content)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	definitions, err := store.QueryDefinitions(ctx, selected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch definitions: %w", err)
+	}
+
+	// Keep the order the terms were selected in
+	order := make(map[string]int, len(selected))
+	for i, term := range selected {
+		order[strings.ToLower(term)] = i
+	}
+	sort.SliceStable(definitions, func(i, j int) bool {
+		return order[strings.ToLower(definitions[i].Metadata.Title)] < order[strings.ToLower(definitions[j].Metadata.Title)]
+	})
+
+	return definitions, nil
+}
+
+// termMatcher finds defined terms in text. The italics that mark defined
+// terms in the Rules are lost in text extraction, so terms are matched by
+// name, ignoring case, plurals and the hyphenation of compound terms.
+type termMatcher struct {
+	pattern *regexp.Regexp
+	terms   []string // Term matched by each capture group
+}
+
+// newTermMatcher compiles a matcher for terms. Longer terms are tried first,
+// so that "Penalty Area" is found rather than a shorter term inside it.
+func newTermMatcher(terms []string) *termMatcher {
+	sorted := append([]string(nil), terms...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	var alternatives []string
+	m := &termMatcher{}
+	for _, term := range sorted {
+		words := strings.Fields(term)
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		alternatives = append(alternatives, "("+strings.Join(words, `[\s-]+`)+")")
+		m.terms = append(m.terms, term)
+	}
+
+	m.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)(?:s|es)?\b`)
+	return m
+}
+
+// find returns the terms used in text, without duplicates and in order of
+// first appearance
+func (m *termMatcher) find(text string) []string {
+	var found []string
+	seen := make(map[int]bool)
+	for _, match := range m.pattern.FindAllStringSubmatchIndex(text, -1) {
+		for group := range m.terms {
+			if match[2+2*group] < 0 {
+				continue
+			}
+			if !seen[group] {
+				seen[group] = true
+				found = append(found, m.terms[group])
+			}
+			break
+		}
+	}
+	return found
+}
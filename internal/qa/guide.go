@@ -0,0 +1,130 @@
+package qa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+// DefaultGuideLimit is the number of interpretations and Model Local Rules
+// added to the contexts of a query
+const DefaultGuideLimit = 2
+
+// expandGuide places the interpretations of the rules among the contexts,
+// and the Model Local Rules referencing them, right after the context they
+// clarify, at most limit of them in all. Contexts are served in rank order,
+// so the best contexts get theirs first. Chunks already among the contexts
+// are not repeated.
+func expandGuide(ctx context.Context, store database.VectorStore, chunks []models.TextChunk,
+	opts Options) ([]models.TextChunk, error) {
+
+	if opts.Guide <= 0 {
+		return chunks, nil
+	}
+
+	seen := make(map[int]bool, len(chunks))
+	for _, chunk := range chunks {
+		seen[chunk.ID] = true
+	}
+
+	// Rules and references are looked up once, whatever the number of contexts
+	byRule := make(map[string][]models.TextChunk)
+	byReference := make(map[string][]models.TextChunk)
+
+	added := 0
+	expanded := make([]models.TextChunk, 0, len(chunks)+opts.Guide)
+	for _, chunk := range chunks {
+		expanded = append(expanded, chunk)
+
+		key := citation.RuleKey(&chunk)
+		if key == "" || chunk.Metadata.ChunkType == models.ChunkTypeInterpretation || added == opts.Guide {
+			continue
+		}
+
+		rule := chunk.Metadata.Section
+		interpretations, ok := byRule[rule]
+		if !ok {
+			found, err := store.QueryByRuleNumber(ctx, rule)
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up interpretations of %s: %w", rule, err)
+			}
+			for _, candidate := range found {
+				if candidate.Metadata.ChunkType == models.ChunkTypeInterpretation {
+					interpretations = append(interpretations, candidate)
+				}
+			}
+			byRule[rule] = interpretations
+		}
+
+		var candidates []models.TextChunk
+		for _, interpretation := range interpretations {
+			parent := citation.RuleKey(&interpretation)
+			if parent == key || citation.IsAncestor(key, parent) || citation.IsAncestor(parent, key) {
+				candidates = append(candidates, interpretation)
+			}
+		}
+
+		for _, number := range sectionAndParts(key) {
+			reference := "Rule " + number
+			localRules, ok := byReference[reference]
+			if !ok {
+				found, err := store.QueryByRuleReference(ctx, reference)
+				if err != nil {
+					return nil, fmt.Errorf("failed to look up Model Local Rules for %s: %w", reference, err)
+				}
+				for _, candidate := range found {
+					if candidate.Metadata.ChunkType == models.ChunkTypeModelLocalRule {
+						localRules = append(localRules, candidate)
+					}
+				}
+				byReference[reference] = localRules
+			}
+			candidates = append(candidates, localRules...)
+		}
+
+		for _, candidate := range candidates {
+			if added == opts.Guide {
+				break
+			}
+			if seen[candidate.ID] {
+				continue
+			}
+			if opts.PlayFormat != "" && candidate.Metadata.PlayFormat != "" && candidate.Metadata.PlayFormat != opts.PlayFormat {
+				continue
+			}
+			seen[candidate.ID] = true
+			candidate.Clarifies = key
+			expanded = append(expanded, candidate)
+			added++
+		}
+	}
+
+	return expanded, nil
+}
+
+// sectionAndParts returns a rule number and the numbers containing it, down
+// to its section, e.g. 18.2b(1), 18.2b and 18.2 for 18.2b(1). Whole rules
+// are referenced too widely to say which Model Local Rules concern them.
+func sectionAndParts(number string) []string {
+	if !strings.Contains(number, ".") {
+		return nil
+	}
+
+	numbers := []string{number}
+	for {
+		last := number[len(number)-1]
+		switch {
+		case last == ')':
+			number = number[:strings.LastIndex(number, "(")]
+		case last >= 'a' && last <= 'z':
+			number = number[:len(number)-1]
+		default:
+			return numbers
+		}
+		numbers = append(numbers, number)
+	}
+}
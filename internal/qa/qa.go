@@ -0,0 +1,385 @@
+package qa
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/embedding"
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/rerank"
+	"golf-rules-rag/internal/retrieval"
+)
+
+const (
+	// DefaultContextLimit is the number of contexts retrieved for a query
+	DefaultContextLimit = 5
+
+	// rerankCandidateFactor is how many more candidates than the context
+	// limit are retrieved for reranking
+	rerankCandidateFactor = 4
+
+	// formatCandidateFactor is how many more candidates are retrieved to make
+	// up for those dropped for being about the other play format
+	formatCandidateFactor = 2
+
+	// expandedContextLimit is the most contexts added by following cross-references
+	expandedContextLimit = 3
+
+	// formatMentionBoost is how many places a context moves up when its text
+	// mentions only the chosen play format, or down when it mentions only the
+	// other
+	formatMentionBoost = 2
+)
+
+var (
+	// Mentions of the play formats in the text of a context
+	matchPlayRe  = regexp.MustCompile(`(?i)\bmatch[\s-]+play\b`)
+	strokePlayRe = regexp.MustCompile(`(?i)\bstroke[\s-]+play\b`)
+)
+
+// Options controls how Answer retrieves contexts and answers
+type Options struct {
+	ContextLimit int
+	RuleFilter   string
+	Retrieval    string // retrieval.StrategyHybrid or retrieval.StrategyVector
+	Hybrid       retrieval.HybridOptions
+	Reranker     rerank.Reranker           // Reorders over-fetched candidates when set
+	ExpandRefs   int                       // Hops of cross-references followed from the contexts; 0 disables
+	RuleGraph    *retrieval.RuleGraph      // Graph followed by ExpandRefs, built from the store when nil
+	History      []models.ConversationTurn // Earlier turns the query may follow up on
+	PlayFormat   string                    // Prefer rules for models.PlayFormatMatch or models.PlayFormatStroke when set
+	Definitions  int                       // Maximum number of definitions of terms used added to the prompt
+	Guide        int                       // Maximum number of interpretations and Model Local Rules added after the contexts they clarify
+	Citations    string                    // citation.ModeFlag, citation.ModeStrip or citation.ModeOff
+}
+
+// Answer retrieves contexts for a query and answers it, streaming the
+// answer to onToken when it is not nil. A query that follows earlier turns
+// is rewritten into a standalone question for retrieval, and answered with
+// the turns included in the prompt, along with the definitions of the terms
+// it and the contexts use. The rules cited in the answer are then checked
+// against the sources and the index. In citation.ModeStrip the answer is
+// not streamed as it is generated, so that the citations stripped from it
+// are never shown: it is passed to onToken whole once checked.
+func Answer(ctx context.Context, query string, store database.VectorStore, embedder embedding.Embedder,
+	llmClient llm.Generator, opts Options, onToken llm.TokenFunc) (*models.Response, error) {
+
+	searchQuery, err := llm.RewriteQuery(ctx, llmClient, opts.History, query)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := Retrieve(ctx, searchQuery, store, embedder, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewrittenQuery string
+	if searchQuery != query {
+		rewrittenQuery = searchQuery
+	}
+
+	if len(chunks) == 0 {
+		// No relevant context found
+		return &models.Response{
+			Answer:         "I couldn't find any relevant information in the golf rules to answer your question.",
+			Sources:        []models.TextChunk{},
+			Timestamp:      time.Now().Format(time.RFC3339),
+			RewrittenQuery: rewrittenQuery,
+			GroundingScore: 1,
+		}, nil
+	}
+
+	definitions, err := expandDefinitions(ctx, store, searchQuery, chunks, opts.Definitions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate answer using LLM
+	var response *models.Response
+	answerOpts := llm.AnswerOptions{History: opts.History, PlayFormat: opts.PlayFormat, Definitions: definitions}
+	buffered := onToken != nil && opts.Citations == citation.ModeStrip
+	if onToken != nil && !buffered {
+		response, err = llmClient.AnswerStream(ctx, query, chunks, answerOpts, onToken)
+	} else {
+		response, err = llmClient.Answer(ctx, query, chunks, answerOpts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate answer: %w", err)
+	}
+	response.RewrittenQuery = rewrittenQuery
+
+	// Check the cited rules; a streamed answer has already been printed as is
+	report, err := citation.Validate(ctx, store, response.Answer, response.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate citations: %w", err)
+	}
+	response.Answer, err = citation.Apply(opts.Citations, response.Answer, report)
+	if err != nil {
+		return nil, err
+	}
+	if buffered {
+		if err := onToken(response.Answer); err != nil {
+			return nil, fmt.Errorf("failed to stream answer: %w", err)
+		}
+	}
+	response.Citations = report.Citations
+	response.GroundingScore = report.Score
+	response.Penalties = collectPenalties(response.Sources)
+	response.Definitions = definitions
+
+	return response, nil
+}
+
+// collectPenalties gathers the penalties stated by chunks, without duplicates
+func collectPenalties(chunks []models.TextChunk) []models.Penalty {
+	var penalties []models.Penalty
+	seen := make(map[models.Penalty]bool)
+	for _, chunk := range chunks {
+		for _, penalty := range chunk.Penalties {
+			key := models.Penalty{Kind: penalty.Kind, Rule: penalty.Rule}
+			if !seen[key] {
+				seen[key] = true
+				penalties = append(penalties, penalty)
+			}
+		}
+	}
+	return penalties
+}
+
+// Retrieve embeds a query and retrieves the contexts for answering it,
+// restricted to RuleFilter when set, reranking over-fetched candidates when
+// a reranker is configured. When a play format is chosen, contexts specific
+// to the other format are dropped, and those mentioning one format in their
+// text are ranked up or down. With ExpandRefs set, the rules the contexts
+// reference and the chunks referencing them are added after the contexts.
+// With Guide set, the interpretations and Model Local Rules of the rules
+// among the contexts follow the contexts they clarify.
+func Retrieve(ctx context.Context, query string, store database.VectorStore, embedder embedding.Embedder,
+	opts Options) ([]models.TextChunk, error) {
+
+	// Create embedding for query
+	queryEmbedding, err := embedder.EmbedText(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query embedding: %w", err)
+	}
+
+	// Over-fetch candidates when they are reranked below
+	limit := opts.ContextLimit
+	if opts.Reranker != nil {
+		limit *= rerankCandidateFactor
+	}
+	if opts.PlayFormat != "" {
+		limit *= formatCandidateFactor
+	}
+
+	var chunks []models.TextChunk
+	switch opts.Retrieval {
+	case retrieval.StrategyHybrid, "":
+		hybridOpts := opts.Hybrid
+		hybridOpts.Rule = opts.RuleFilter
+		chunks, err = retrieval.Hybrid(ctx, store, queryEmbedding, query, limit, hybridOpts)
+	case retrieval.StrategyVector:
+		chunks, err = queryVector(ctx, query, queryEmbedding, store, opts.RuleFilter, limit)
+	default:
+		err = fmt.Errorf("unknown retrieval strategy %q (expected %q or %q)",
+			opts.Retrieval, retrieval.StrategyHybrid, retrieval.StrategyVector)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve contexts: %w", err)
+	}
+
+	if opts.PlayFormat != "" {
+		chunks = boostPlayFormat(filterPlayFormat(chunks, opts.PlayFormat), opts.PlayFormat)
+	}
+
+	if opts.Reranker != nil && len(chunks) > 0 {
+		chunks, err = opts.Reranker.Rerank(ctx, query, chunks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rerank contexts: %w", err)
+		}
+	}
+
+	if len(chunks) > opts.ContextLimit {
+		chunks = chunks[:opts.ContextLimit]
+	}
+
+	if opts.ExpandRefs > 0 && len(chunks) > 0 {
+		chunks, err = expandReferences(ctx, store, chunks, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chunks, err = expandGuide(ctx, store, chunks, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+// expandReferences appends the best chunks reached by following the
+// cross-references of the contexts
+func expandReferences(ctx context.Context, store database.VectorStore, chunks []models.TextChunk,
+	opts Options) ([]models.TextChunk, error) {
+
+	graph := opts.RuleGraph
+	if graph == nil {
+		var err error
+		graph, err = retrieval.BuildRuleGraph(ctx, store)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expanded := graph.Expand(chunks, opts.ExpandRefs)
+	if opts.PlayFormat != "" {
+		expanded = filterPlayFormat(expanded, opts.PlayFormat)
+	}
+	if len(expanded) > expandedContextLimit {
+		expanded = expanded[:expandedContextLimit]
+	}
+
+	return append(chunks, expanded...), nil
+}
+
+// filterPlayFormat drops the chunks that only apply to a play format other
+// than format, keeping those that apply to both
+func filterPlayFormat(chunks []models.TextChunk, format string) []models.TextChunk {
+	filtered := chunks[:0]
+	for _, chunk := range chunks {
+		if chunk.Metadata.PlayFormat == "" || chunk.Metadata.PlayFormat == format {
+			filtered = append(filtered, chunk)
+		}
+	}
+	return filtered
+}
+
+// boostPlayFormat moves the chunks whose text mentions only format up
+// formatMentionBoost places, and those mentioning only the other format down
+// as many, keeping the order of the rest
+func boostPlayFormat(chunks []models.TextChunk, format string) []models.TextChunk {
+	ranks := make([]int, len(chunks))
+	for i, chunk := range chunks {
+		ranks[i] = i
+		match := matchPlayRe.MatchString(chunk.Content)
+		stroke := strokePlayRe.MatchString(chunk.Content)
+		if match == stroke {
+			continue
+		}
+		if match == (format == models.PlayFormatMatch) {
+			ranks[i] -= formatMentionBoost
+		} else {
+			ranks[i] += formatMentionBoost
+		}
+	}
+
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return ranks[order[a]] < ranks[order[b]]
+	})
+
+	boosted := make([]models.TextChunk, len(chunks))
+	for i, j := range order {
+		boosted[i] = chunks[j]
+	}
+	return boosted
+}
+
+// queryVector retrieves contexts by vector similarity, restricted to the rule
+// filter or to referenced rules, or boosted by golf terms when the query
+// mentions them
+func queryVector(ctx context.Context, query string, queryEmbedding []float64, store database.VectorStore,
+	ruleFilter string, limit int) ([]models.TextChunk, error) {
+
+	// Extract rule references and golf terms
+	queryRuleRefs := extractRuleReferences(query)
+	golfTerms := identifyGolfTerms(query)
+
+	if ruleFilter != "" {
+		return retrieval.QuerySimilarInRule(ctx, store, queryEmbedding, ruleFilter, limit)
+	} else if len(queryRuleRefs) > 0 {
+		// Use rule-specific querying
+		return store.QuerySimilarWithStructure(ctx, queryEmbedding, query, limit)
+	} else if len(golfTerms) > 0 {
+		// Use term-based querying for golf-specific terms
+		return store.QuerySimilarWithTerms(ctx, queryEmbedding, golfTerms, limit)
+	}
+
+	// Fall back to regular similarity search
+	return store.QuerySimilar(ctx, queryEmbedding, limit)
+}
+
+// identifyGolfTerms extracts golf-specific terms from the query
+func identifyGolfTerms(query string) []string {
+	var terms []string
+
+	// Golf-specific term patterns
+	patterns := map[string]string{
+		"penalty area":        "penalty area",
+		"bunker":              "bunker",
+		"putting green":       "putting green",
+		"teeing area":         "teeing area",
+		"loose impediment":    "loose impediment",
+		"obstruction":         "obstruction",
+		"out of bounds":       "out of bounds",
+		"OB":                  "out of bounds",
+		"unplayable":          "unplayable ball",
+		"stroke and distance": "stroke-and-distance",
+		// Add more golf terms...
+	}
+
+	for pattern, term := range patterns {
+		if strings.Contains(strings.ToLower(query), pattern) {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// extractRuleReferences extracts rule references from a query
+func extractRuleReferences(query string) []string {
+	rulePattern := regexp.MustCompile(`Rule\s+(\d+)(\.\d+)?([a-z])?(\(\d+\))?`)
+	matches := rulePattern.FindAllStringSubmatch(query, -1)
+
+	var ruleRefs []string
+	for _, match := range matches {
+		if len(match) > 0 {
+			// Full match is at index 0
+			ruleRef := match[0]
+			ruleRefs = append(ruleRefs, ruleRef)
+
+			// Also add the main rule number for broader context
+			if len(match) > 1 && match[1] != "" {
+				mainRule := "Rule " + match[1]
+				if !contains(ruleRefs, mainRule) {
+					ruleRefs = append(ruleRefs, mainRule)
+				}
+			}
+		}
+	}
+
+	return ruleRefs
+}
+
+// contains checks if a string slice contains a specific value
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
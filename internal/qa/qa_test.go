@@ -0,0 +1,341 @@
+package qa
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/embedding"
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/retrieval"
+)
+
+// recordingStore records which queries retrieval sends to the store
+type recordingStore struct {
+	database.VectorStore
+	calls []string
+}
+
+func (s *recordingStore) QuerySimilar(ctx context.Context, embedding []float64, limit int) ([]models.TextChunk, error) {
+	s.calls = append(s.calls, "similar")
+	return s.VectorStore.QuerySimilar(ctx, embedding, limit)
+}
+
+func (s *recordingStore) QuerySimilarWithStructure(ctx context.Context, embedding []float64, query string,
+	limit int) ([]models.TextChunk, error) {
+
+	s.calls = append(s.calls, "structure")
+	return s.VectorStore.QuerySimilarWithStructure(ctx, embedding, query, limit)
+}
+
+func (s *recordingStore) QuerySimilarWithTerms(ctx context.Context, embedding []float64, terms []string,
+	limit int) ([]models.TextChunk, error) {
+
+	s.calls = append(s.calls, "terms")
+	return s.VectorStore.QuerySimilarWithTerms(ctx, embedding, terms, limit)
+}
+
+func (s *recordingStore) QueryFullText(ctx context.Context, query string, limit int) ([]models.TextChunk, error) {
+	s.calls = append(s.calls, "fulltext")
+	return s.VectorStore.QueryFullText(ctx, query, limit)
+}
+
+// newTestStore returns a file store holding a few rules embedded with embedder
+func newTestStore(t *testing.T, embedder embedding.Embedder) database.VectorStore {
+	t.Helper()
+	ctx := context.Background()
+
+	store, err := database.NewFileStore(t.TempDir() + "/test.index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RegisterIndex(ctx, models.IndexInfo{Embedding: models.EmbeddingInfo{Dimension: 64}}); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := []models.TextChunk{
+		{Content: "A player may remove a loose impediment in a bunker before playing the ball.",
+			Metadata: models.Metadata{Section: "Rule 12", Subsection: "12.2a", Hierarchy: "Rule 12 > 12.2 > 12.2a"}},
+		{Content: "The ball must be dropped in the relief area and come to rest in it.",
+			Metadata:        models.Metadata{Section: "Rule 14", Subsection: "14.3c", Hierarchy: "Rule 14 > 14.3 > 14.3c"},
+			CrossReferences: []string{"Rule 14.3"}},
+		{Content: "A ball dropped in the wrong way must be dropped again.",
+			Metadata: models.Metadata{Section: "Rule 14", Subsection: "14.3b", Hierarchy: "Rule 14 > 14.3 > 14.3b"}},
+		{Content: "The flagstick may be left in the hole when a ball is played from the putting green.",
+			Metadata: models.Metadata{Section: "Rule 13", Subsection: "13.2a", Hierarchy: "Rule 13 > 13.2 > 13.2a"}},
+		{Content: "In match play a player may concede the opponent's next stroke or the hole.",
+			Metadata: models.Metadata{Section: "Rule 3", Subsection: "3.2b", Hierarchy: "Rule 3 > 3.2 > 3.2b",
+				PlayFormat: models.PlayFormatMatch}},
+		{Content: "In stroke play a player must hole out on every hole and the ball must be played again.",
+			Metadata: models.Metadata{Section: "Rule 3", Subsection: "3.3c", Hierarchy: "Rule 3 > 3.3 > 3.3c",
+				PlayFormat: models.PlayFormatStroke}},
+	}
+
+	embedded, err := embedder.EmbedBatch(ctx, chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range embedded {
+		if err := store.StoreTextChunk(ctx, &embedded[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func keys(chunks []models.TextChunk) []string {
+	result := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		result = append(result, chunk.Metadata.Subsection)
+	}
+	return result
+}
+
+func TestRetrieve(t *testing.T) {
+	embedder := embedding.NewFakeEmbedder(64)
+	store := newTestStore(t, embedder)
+
+	tests := []struct {
+		name  string
+		query string
+		opts  Options
+		calls []string // Store queries made, in order
+		only  []string // Subsections every context must be among, when set
+		first string   // Subsection of the best context, when set
+		drops string   // Subsection that must not be retrieved, when set
+	}{
+		{
+			name:  "vector",
+			query: "Can the flagstick stay in?",
+			opts:  Options{Retrieval: retrieval.StrategyVector},
+			calls: []string{"similar"},
+			first: "13.2a",
+		},
+		{
+			name:  "vector with a rule reference",
+			query: "When must a ball be dropped again under Rule 14?",
+			opts:  Options{Retrieval: retrieval.StrategyVector},
+			calls: []string{"structure"},
+			only:  []string{"14.3b", "14.3c"},
+		},
+		{
+			name:  "vector with golf terms",
+			query: "Can I touch the sand in a bunker?",
+			opts:  Options{Retrieval: retrieval.StrategyVector},
+			calls: []string{"terms"},
+			first: "12.2a",
+		},
+		{
+			name:  "vector with a rule filter",
+			query: "What happens to a ball dropped in the wrong way?",
+			opts:  Options{Retrieval: retrieval.StrategyVector, RuleFilter: "Rule 12"},
+			calls: []string{"structure"},
+			only:  []string{"12.2a"},
+		},
+		{
+			name:  "hybrid by default",
+			query: "What happens to a ball dropped in the wrong way?",
+			opts:  Options{Hybrid: retrieval.DefaultHybridOptions()},
+			calls: []string{"structure", "fulltext"},
+			first: "14.3b",
+		},
+		{
+			name:  "hybrid with a rule filter",
+			query: "What happens to a ball dropped in the wrong way?",
+			opts:  Options{Hybrid: retrieval.DefaultHybridOptions(), RuleFilter: "13"},
+			calls: []string{"structure", "fulltext"},
+			only:  []string{"13.2a"},
+		},
+		{
+			name:  "hybrid with a subsection filter",
+			query: "What happens to a ball dropped in the wrong way?",
+			opts:  Options{Retrieval: retrieval.StrategyHybrid, Hybrid: retrieval.DefaultHybridOptions(), RuleFilter: "14.3c"},
+			calls: []string{"structure", "fulltext"},
+			only:  []string{"14.3c"},
+		},
+		{
+			name:  "match play drops stroke play rules",
+			query: "Must a player hole out in stroke play or match play?",
+			opts:  Options{Hybrid: retrieval.DefaultHybridOptions(), PlayFormat: models.PlayFormatMatch},
+			calls: []string{"structure", "fulltext"},
+			first: "3.2b",
+			drops: "3.3c",
+		},
+		{
+			name:  "stroke play drops match play rules",
+			query: "Must a player hole out in stroke play or match play?",
+			opts:  Options{Retrieval: retrieval.StrategyVector, PlayFormat: models.PlayFormatStroke},
+			calls: []string{"similar"},
+			first: "3.3c",
+			drops: "3.2b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recordingStore{VectorStore: store}
+			tt.opts.ContextLimit = 3
+
+			chunks, err := Retrieve(context.Background(), tt.query, recorder, embedder, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := keys(chunks)
+
+			if !reflect.DeepEqual(recorder.calls, tt.calls) {
+				t.Errorf("store queries = %q, want %q", recorder.calls, tt.calls)
+			}
+			if len(chunks) == 0 || len(chunks) > tt.opts.ContextLimit {
+				t.Fatalf("retrieved %d contexts %q, want 1 to %d", len(chunks), got, tt.opts.ContextLimit)
+			}
+			if tt.first != "" && got[0] != tt.first {
+				t.Errorf("contexts = %q, want %s first", got, tt.first)
+			}
+			for _, key := range got {
+				if tt.only != nil && !contains(tt.only, key) {
+					t.Errorf("contexts = %q, want only %q", got, tt.only)
+				}
+				if key == tt.drops {
+					t.Errorf("contexts = %q, want no %s", got, tt.drops)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrieveUnknownStrategy(t *testing.T) {
+	embedder := embedding.NewFakeEmbedder(64)
+	store := newTestStore(t, embedder)
+
+	_, err := Retrieve(context.Background(), "flagstick", store, embedder, Options{ContextLimit: 3, Retrieval: "keyword"})
+	if err == nil || !strings.Contains(err.Error(), "unknown retrieval strategy") {
+		t.Errorf("Retrieve() error = %v, want an unknown strategy error", err)
+	}
+}
+
+func TestAnswerFollowUp(t *testing.T) {
+	ctx := context.Background()
+	embedder := embedding.NewFakeEmbedder(64)
+	store := newTestStore(t, embedder)
+	opts := Options{ContextLimit: 2, Retrieval: retrieval.StrategyVector}
+
+	first, err := Answer(ctx, "Can the flagstick stay in the hole?", store, embedder, llm.NewFakeLLM(), opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.RewrittenQuery != "" {
+		t.Errorf("first question was rewritten to %q", first.RewrittenQuery)
+	}
+
+	opts.History = []models.ConversationTurn{{Question: "Can the flagstick stay in the hole?", Answer: first.Answer}}
+	followUp, err := Answer(ctx, "What if I am on the putting green?", store, embedder, llm.NewFakeLLM(), opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Can the flagstick stay in the hole? What if I am on the putting green?"; followUp.RewrittenQuery != want {
+		t.Errorf("RewrittenQuery = %q, want %q", followUp.RewrittenQuery, want)
+	}
+	if got := keys(followUp.Sources); len(got) == 0 || got[0] != "13.2a" {
+		t.Errorf("follow-up sources = %q, want 13.2a first", got)
+	}
+	if !strings.HasPrefix(followUp.Answer, "Fake answer to: What if I am on the putting green?") {
+		t.Errorf("follow-up answered %q, not the question asked", followUp.Answer)
+	}
+}
+
+// citingLLM is a fake LLM whose answers also cite a rule that does not exist
+type citingLLM struct {
+	*llm.FakeLLM
+}
+
+func (c citingLLM) Answer(ctx context.Context, query string, contexts []models.TextChunk,
+	opts llm.AnswerOptions) (*models.Response, error) {
+
+	response, err := c.FakeLLM.Answer(ctx, query, contexts, opts)
+	if err != nil {
+		return nil, err
+	}
+	response.Answer += " See Rule 99.1."
+	return response, nil
+}
+
+func (c citingLLM) AnswerStream(ctx context.Context, query string, contexts []models.TextChunk,
+	opts llm.AnswerOptions, onToken llm.TokenFunc) (*models.Response, error) {
+
+	response, err := c.Answer(ctx, query, contexts, opts)
+	if err != nil {
+		return nil, err
+	}
+	return response, onToken(response.Answer)
+}
+
+func TestAnswerStreamCitations(t *testing.T) {
+	embedder := embedding.NewFakeEmbedder(64)
+	store := newTestStore(t, embedder)
+
+	tests := []struct {
+		mode string
+		raw  bool // Whether the answer is streamed before its citations are checked
+	}{
+		{citation.ModeFlag, true},
+		{citation.ModeStrip, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			opts := Options{ContextLimit: 2, Retrieval: retrieval.StrategyVector, Citations: tt.mode}
+
+			var streamed strings.Builder
+			response, err := Answer(context.Background(), "Can the flagstick stay in?", store, embedder,
+				citingLLM{llm.NewFakeLLM()}, opts, func(token string) error {
+					streamed.WriteString(token)
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if raw := streamed.String() != response.Answer; raw != tt.raw {
+				t.Errorf("streamed %q, answered %q; want streamed before checking %v", streamed.String(),
+					response.Answer, tt.raw)
+			}
+			if strings.Contains(response.Answer, "Rule 99.1.") {
+				t.Errorf("answer %q leaves the unknown citation unchecked", response.Answer)
+			}
+		})
+	}
+}
+
+func TestBoostPlayFormat(t *testing.T) {
+	chunks := []models.TextChunk{
+		{ID: 1, Content: "The ball must be dropped in the relief area."},
+		{ID: 2, Content: "In stroke play the player gets two penalty strokes."},
+		{ID: 3, Content: "The player may lift the ball."},
+		{ID: 4, Content: "In match play or stroke play the player must hole out."},
+		{ID: 5, Content: "In match play the player loses the hole."},
+	}
+
+	tests := []struct {
+		format string
+		want   []int
+	}{
+		{models.PlayFormatMatch, []int{1, 3, 5, 2, 4}},
+		{models.PlayFormatStroke, []int{2, 1, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		boosted := boostPlayFormat(chunks, tt.format)
+		var got []int
+		for _, chunk := range boosted {
+			got = append(got, chunk.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("boostPlayFormat(%s) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+}
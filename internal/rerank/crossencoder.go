@@ -0,0 +1,57 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+
+	"golf-rules-rag/internal/models"
+	"golf-rules-rag/internal/openai"
+)
+
+// CrossEncoderReranker scores candidates with a cross-encoder reranking
+// model (e.g., bge-reranker) behind an OpenAI-compatible /v1/rerank endpoint
+type CrossEncoderReranker struct {
+	Client *openai.Client
+	Model  string
+}
+
+// NewCrossEncoderReranker creates a reranker for the OpenAI-compatible server at baseURL
+func NewCrossEncoderReranker(baseURL, apiKey, model string) *CrossEncoderReranker {
+	return &CrossEncoderReranker{
+		Client: openai.NewClient(baseURL, apiKey),
+		Model:  model,
+	}
+}
+
+// Rerank scores all candidates in a single request and orders them by score
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, query string, candidates []models.TextChunk) ([]models.TextChunk, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	documents := make([]string, len(candidates))
+	for i, chunk := range candidates {
+		documents[i] = chunk.Content
+	}
+
+	resp, err := r.Client.Rerank(ctx, &openai.RerankRequest{
+		Model:     r.Model,
+		Query:     query,
+		Documents: documents,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank: %w", err)
+	}
+
+	reranked := make([]models.TextChunk, len(candidates))
+	copy(reranked, candidates)
+	for _, result := range resp.Results {
+		if result.Index < 0 || result.Index >= len(reranked) {
+			return nil, fmt.Errorf("rerank result index %d out of range", result.Index)
+		}
+		reranked[result.Index].RerankScore = result.RelevanceScore
+	}
+
+	sortByScore(reranked)
+	return reranked, nil
+}
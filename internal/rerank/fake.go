@@ -0,0 +1,58 @@
+package rerank
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"golf-rules-rag/internal/models"
+)
+
+// FakeReranker is a deterministic Reranker for offline tests. It scores each
+// candidate by the fraction of query words found in its content.
+type FakeReranker struct{}
+
+// NewFakeReranker creates a fake reranker
+func NewFakeReranker() *FakeReranker {
+	return &FakeReranker{}
+}
+
+// Rerank orders candidates by query word overlap
+func (f *FakeReranker) Rerank(ctx context.Context, query string, candidates []models.TextChunk) ([]models.TextChunk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	queryWords := words(query)
+
+	reranked := make([]models.TextChunk, len(candidates))
+	copy(reranked, candidates)
+	for i := range reranked {
+		if len(queryWords) == 0 {
+			continue
+		}
+
+		content := make(map[string]bool)
+		for _, word := range words(reranked[i].Content) {
+			content[word] = true
+		}
+
+		var found int
+		for _, word := range queryWords {
+			if content[word] {
+				found++
+			}
+		}
+		reranked[i].RerankScore = float64(found) / float64(len(queryWords))
+	}
+
+	sortByScore(reranked)
+	return reranked, nil
+}
+
+// words splits text into lower-cased words
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,89 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+)
+
+// DefaultMaxConcurrent is how many relevance prompts LLMReranker runs at once
+const DefaultMaxConcurrent = 4
+
+// ratingPattern finds the rating in an LLM reply such as "8" or "Rating: 7/10"
+var ratingPattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// LLMReranker scores each candidate by asking a language model to rate its
+// relevance from 0 to 10. Any Generator works, including reranker models
+// served by Ollama.
+type LLMReranker struct {
+	Generator     llm.Generator
+	MaxConcurrent int
+}
+
+// NewLLMReranker creates a reranker prompting the given generator
+func NewLLMReranker(generator llm.Generator) *LLMReranker {
+	return &LLMReranker{Generator: generator, MaxConcurrent: DefaultMaxConcurrent}
+}
+
+// Rerank rates every candidate and orders them by rating, scaled to 0-1
+func (r *LLMReranker) Rerank(ctx context.Context, query string, candidates []models.TextChunk) ([]models.TextChunk, error) {
+	maxConcurrent := r.MaxConcurrent
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	reranked := make([]models.TextChunk, len(candidates))
+	copy(reranked, candidates)
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrent)
+	errChan := make(chan error, len(reranked))
+
+	for i := range reranked {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				wg.Done()
+				<-semaphore
+			}()
+
+			reply, err := r.Generator.GenerateResponse(ctx, llm.GenerateRelevancePrompt(query, reranked[i]))
+			if err != nil {
+				errChan <- fmt.Errorf("failed to rate chunk %d: %w", reranked[i].ID, err)
+				return
+			}
+			reranked[i].RerankScore = parseRating(reply)
+		}(i)
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	sortByScore(reranked)
+	return reranked, nil
+}
+
+// parseRating extracts a 0-10 rating from a reply and scales it to 0-1.
+// Replies without a number rate as irrelevant.
+func parseRating(reply string) float64 {
+	match := ratingPattern.FindString(reply)
+	if match == "" {
+		return 0
+	}
+	rating, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0
+	}
+	return min(rating, 10) / 10
+}
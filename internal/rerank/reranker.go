@@ -0,0 +1,68 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golf-rules-rag/internal/llm"
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// Supported reranking methods
+	MethodLLM          = "llm"
+	MethodCrossEncoder = "cross-encoder"
+)
+
+// Reranker rescores retrieved chunks against the query
+type Reranker interface {
+	// Rerank returns the candidates ordered by descending relevance to the
+	// query, with RerankScore set on each
+	Rerank(ctx context.Context, query string, candidates []models.TextChunk) ([]models.TextChunk, error)
+}
+
+// Config selects and configures a reranker
+type Config struct {
+	Method     string // MethodLLM or MethodCrossEncoder
+	Provider   string // LLM provider used by MethodLLM
+	OllamaHost string // Ollama host, defaults to OLLAMA_HOST
+	BaseURL    string // Base URL of an OpenAI-compatible server
+	APIKey     string
+	Model      string
+}
+
+// New creates the reranker for the configured method. The fake provider
+// always gets the deterministic FakeReranker.
+func New(cfg Config) (Reranker, error) {
+	if cfg.Provider == llm.ProviderFake {
+		return NewFakeReranker(), nil
+	}
+
+	switch cfg.Method {
+	case MethodLLM:
+		generator, err := llm.New(llm.Config{
+			Provider:   cfg.Provider,
+			OllamaHost: cfg.OllamaHost,
+			BaseURL:    cfg.BaseURL,
+			APIKey:     cfg.APIKey,
+			Model:      cfg.Model,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewLLMReranker(generator), nil
+	case MethodCrossEncoder:
+		return NewCrossEncoderReranker(cfg.BaseURL, cfg.APIKey, cfg.Model), nil
+	default:
+		return nil, fmt.Errorf("unknown rerank method %q (expected %q or %q)", cfg.Method, MethodLLM, MethodCrossEncoder)
+	}
+}
+
+// sortByScore orders chunks by descending RerankScore, keeping the retrieval
+// order of equally scored chunks
+func sortByScore(chunks []models.TextChunk) {
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].RerankScore > chunks[j].RerankScore
+	})
+}
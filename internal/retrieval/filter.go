@@ -0,0 +1,70 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+// ruleCandidateFactor is how many more candidates a ranking fetches when it
+// is restricted to a rule, to make up for those outside it
+const ruleCandidateFactor = 5
+
+// FilterRule keeps the chunks of a rule and its parts, e.g. every chunk of
+// 14.3c and below for "Rule 14.3c" or "14.3c", in their order
+func FilterRule(chunks []models.TextChunk, rule string) []models.TextChunk {
+	number := ruleNumber(rule)
+
+	filtered := make([]models.TextChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		key := citation.RuleKey(&chunk)
+		if key == number || citation.IsAncestor(number, key) {
+			filtered = append(filtered, chunk)
+		}
+	}
+	return filtered
+}
+
+// QuerySimilarInRule ranks the chunks of a rule and its parts by vector
+// similarity. The store narrows the search to the whole rule; parts of it
+// are then picked from the over-fetched candidates.
+func QuerySimilarInRule(ctx context.Context, store database.VectorStore, embedding []float64, rule string,
+	limit int) ([]models.TextChunk, error) {
+
+	number := ruleNumber(rule)
+	main, _, _ := strings.Cut(number, ".")
+
+	chunks, err := store.QuerySimilarWithStructure(ctx, embedding, "Rule "+main, limit*ruleCandidateFactor)
+	if err != nil {
+		return nil, err
+	}
+	return truncate(FilterRule(chunks, number), limit), nil
+}
+
+// queryFullTextInRule ranks the chunks of a rule and its parts by full-text
+// relevance to the query
+func queryFullTextInRule(ctx context.Context, store database.VectorStore, query, rule string,
+	limit int) ([]models.TextChunk, error) {
+
+	chunks, err := store.QueryFullText(ctx, query, limit*ruleCandidateFactor)
+	if err != nil {
+		return nil, err
+	}
+	return truncate(FilterRule(chunks, rule), limit), nil
+}
+
+// ruleNumber returns the number of a rule filter such as "Rule 14" or "14.3c"
+func ruleNumber(rule string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rule), "Rule "))
+}
+
+// truncate returns at most limit chunks
+func truncate(chunks []models.TextChunk, limit int) []models.TextChunk {
+	if len(chunks) > limit {
+		return chunks[:limit]
+	}
+	return chunks
+}
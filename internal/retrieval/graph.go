@@ -0,0 +1,193 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golf-rules-rag/internal/citation"
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+// Share of a chunk's score passed on along each kind of reference. Rules a
+// chunk references usually complete it; chunks referencing it are more often
+// about something else.
+const (
+	referencedWeight  = 0.5
+	referencingWeight = 0.25
+)
+
+// RuleGraph links the chunks of an index by the rules they reference
+type RuleGraph struct {
+	chunks       map[int]models.TextChunk
+	references   map[int][]int    // Chunks holding the rules each chunk references
+	referencedBy map[int][]int    // Chunks referencing the rule each chunk holds
+	byKey        map[string][]int // Chunks holding each rule number
+	keys         []string         // Rule numbers in the order their chunks were loaded
+}
+
+// BuildRuleGraph loads the chunks of the index the store is scoped to and
+// links them by their cross-references
+func BuildRuleGraph(ctx context.Context, store database.VectorStore) (*RuleGraph, error) {
+	chunks, err := store.ListChunks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunks for the rule graph: %w", err)
+	}
+	return NewRuleGraph(chunks), nil
+}
+
+// NewRuleGraph links chunks by their cross-references. A reference such as
+// "Rule 14.3" leads to the chunks of the rule and of its parts, or failing
+// that to the chunk of the closest numbered part containing it.
+func NewRuleGraph(chunks []models.TextChunk) *RuleGraph {
+	g := &RuleGraph{
+		chunks:       make(map[int]models.TextChunk, len(chunks)),
+		references:   make(map[int][]int),
+		referencedBy: make(map[int][]int),
+		byKey:        make(map[string][]int),
+	}
+
+	for _, chunk := range chunks {
+		g.chunks[chunk.ID] = chunk
+		key := citation.RuleKey(&chunk)
+		if key == "" {
+			continue
+		}
+		if _, ok := g.byKey[key]; !ok {
+			g.keys = append(g.keys, key)
+		}
+		g.byKey[key] = append(g.byKey[key], chunk.ID)
+	}
+
+	linked := make(map[[2]int]bool)
+	for _, chunk := range chunks {
+		for _, ref := range chunk.CrossReferences {
+			number := strings.TrimPrefix(ref, "Rule ")
+			for _, target := range resolve(number, g.byKey, g.keys) {
+				edge := [2]int{chunk.ID, target}
+				if target == chunk.ID || linked[edge] {
+					continue
+				}
+				linked[edge] = true
+				g.references[chunk.ID] = append(g.references[chunk.ID], target)
+				g.referencedBy[target] = append(g.referencedBy[target], chunk.ID)
+			}
+		}
+	}
+
+	return g
+}
+
+// resolve returns the chunks holding a rule number
+func resolve(number string, byKey map[string][]int, keys []string) []int {
+	var targets []int
+	for _, key := range keys {
+		if key == number || citation.IsAncestor(number, key) {
+			targets = append(targets, byKey[key]...)
+		}
+	}
+	if len(targets) > 0 {
+		return targets
+	}
+
+	// Fall back to the closest part containing the number, e.g. 14.3 for 14.3b
+	closest := ""
+	for _, key := range keys {
+		if strings.Contains(key, ".") && citation.IsAncestor(key, number) && len(key) > len(closest) {
+			closest = key
+		}
+	}
+	return byKey[closest]
+}
+
+// Expand follows the cross-references of chunks for up to hops hops, both to
+// the rules they reference and to the chunks referencing them, and returns
+// the chunks reached that are not among them, best scored first. The chunks
+// score 1/rank, and every hop passes on a share of the score of the chunks it
+// starts from, so chunks linked to several top hits rank highest.
+func (g *RuleGraph) Expand(chunks []models.TextChunk, hops int) []models.TextChunk {
+	visited := make(map[int]bool, len(chunks))
+	scores := make(map[int]float64)
+	var frontier []int
+	for rank, chunk := range chunks {
+		if visited[chunk.ID] {
+			continue
+		}
+		visited[chunk.ID] = true
+		scores[chunk.ID] = 1 / float64(rank+1)
+		frontier = append(frontier, chunk.ID)
+	}
+
+	var reached []int
+	for hop := 0; hop < hops && len(frontier) > 0; hop++ {
+		var next []int
+		nextScores := make(map[int]float64)
+		follow := func(from int, targets []int, weight float64) {
+			for _, target := range targets {
+				if visited[target] {
+					continue
+				}
+				if _, ok := nextScores[target]; !ok {
+					next = append(next, target)
+				}
+				nextScores[target] += scores[from] * weight
+			}
+		}
+		for _, id := range frontier {
+			follow(id, g.references[id], referencedWeight)
+			follow(id, g.referencedBy[id], referencingWeight)
+		}
+
+		for _, id := range next {
+			visited[id] = true
+			scores[id] = nextScores[id]
+		}
+		reached = append(reached, next...)
+		frontier = next
+	}
+
+	// Ties keep the order the chunks were reached in
+	sort.SliceStable(reached, func(i, j int) bool {
+		return scores[reached[i]] > scores[reached[j]]
+	})
+
+	expanded := make([]models.TextChunk, 0, len(reached))
+	for _, id := range reached {
+		chunk := g.chunks[id]
+		chunk.ReferenceScore = scores[id]
+		expanded = append(expanded, chunk)
+	}
+	return expanded
+}
+
+// Resolve returns the chunks holding a rule number such as "16.1c": those
+// of the rule and of its parts, or failing that the chunk of the closest
+// numbered part containing it. Chunks keep the order they were loaded in.
+func (g *RuleGraph) Resolve(number string) []models.TextChunk {
+	var chunks []models.TextChunk
+	for _, id := range resolve(number, g.byKey, g.keys) {
+		chunks = append(chunks, g.chunks[id])
+	}
+	return chunks
+}
+
+// References returns the chunks holding the rules a chunk references
+func (g *RuleGraph) References(id int) []models.TextChunk {
+	return g.lookup(g.references[id])
+}
+
+// ReferencedBy returns the chunks referencing the rule a chunk holds
+func (g *RuleGraph) ReferencedBy(id int) []models.TextChunk {
+	return g.lookup(g.referencedBy[id])
+}
+
+// lookup returns the chunks with the given IDs
+func (g *RuleGraph) lookup(ids []int) []models.TextChunk {
+	chunks := make([]models.TextChunk, 0, len(ids))
+	for _, id := range ids {
+		chunks = append(chunks, g.chunks[id])
+	}
+	return chunks
+}
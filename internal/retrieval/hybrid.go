@@ -0,0 +1,135 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+const (
+	// Supported retrieval strategies
+	StrategyHybrid = "hybrid"
+	StrategyVector = "vector"
+
+	// DefaultRRFK is the rank constant of reciprocal rank fusion. Larger
+	// values flatten the difference between the top ranks.
+	DefaultRRFK = 60
+
+	// candidateFactor is how many more candidates than the limit each ranking fetches
+	candidateFactor = 4
+)
+
+// HybridOptions tunes how vector and full-text rankings are fused
+type HybridOptions struct {
+	VectorWeight float64 // Weight of the vector similarity ranking; zero disables it
+	TextWeight   float64 // Weight of the full-text ranking; zero disables it
+	K            int     // Reciprocal rank fusion constant, defaults to DefaultRRFK
+	Rule         string  // Restricts both rankings to a rule and its parts, e.g. "Rule 14" or "14.3c", when set
+}
+
+// DefaultHybridOptions weights vector and full-text rankings equally
+func DefaultHybridOptions() HybridOptions {
+	return HybridOptions{VectorWeight: 1, TextWeight: 1, K: DefaultRRFK}
+}
+
+// Ranking is a list of chunks ordered best first, and the weight its ranks
+// carry when fused with other rankings
+type Ranking struct {
+	Chunks []models.TextChunk
+	Weight float64
+}
+
+// Hybrid retrieves chunks by fusing a vector similarity ranking with a
+// full-text ranking of the query. Queries that reference rules use the
+// structure-aware similarity search for the vector ranking. With a rule
+// filter, both rankings are restricted to the rule before they are fused.
+func Hybrid(ctx context.Context, store database.VectorStore, embedding []float64, query string,
+	limit int, opts HybridOptions) ([]models.TextChunk, error) {
+
+	if opts.VectorWeight < 0 || opts.TextWeight < 0 {
+		return nil, fmt.Errorf("hybrid weights must not be negative")
+	}
+	if opts.VectorWeight == 0 && opts.TextWeight == 0 {
+		return nil, fmt.Errorf("at least one hybrid weight must be positive")
+	}
+
+	candidates := limit * candidateFactor
+	var rankings []Ranking
+
+	if opts.VectorWeight > 0 {
+		var chunks []models.TextChunk
+		var err error
+		if opts.Rule != "" {
+			chunks, err = QuerySimilarInRule(ctx, store, embedding, opts.Rule, candidates)
+		} else {
+			chunks, err = store.QuerySimilarWithStructure(ctx, embedding, query, candidates)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, Ranking{Chunks: chunks, Weight: opts.VectorWeight})
+	}
+
+	if opts.TextWeight > 0 {
+		var chunks []models.TextChunk
+		var err error
+		if opts.Rule != "" {
+			chunks, err = queryFullTextInRule(ctx, store, query, opts.Rule, candidates)
+		} else {
+			chunks, err = store.QueryFullText(ctx, query, candidates)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, Ranking{Chunks: chunks, Weight: opts.TextWeight})
+	}
+
+	return ReciprocalRankFusion(opts.K, limit, rankings...), nil
+}
+
+// ReciprocalRankFusion merges rankings by scoring each chunk with the sum of
+// weight / (k + rank) over the rankings it appears in, ranks starting at 1.
+// Chunks are identified by ID; ties keep the order of first appearance.
+func ReciprocalRankFusion(k, limit int, rankings ...Ranking) []models.TextChunk {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	type fused struct {
+		chunk models.TextChunk
+		score float64
+	}
+
+	byID := make(map[int]*fused)
+	var results []*fused
+
+	for _, ranking := range rankings {
+		for rank, chunk := range ranking.Chunks {
+			f, ok := byID[chunk.ID]
+			if !ok {
+				f = &fused{chunk: chunk}
+				byID[chunk.ID] = f
+				results = append(results, f)
+			}
+			f.score += ranking.Weight / float64(k+rank+1)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	chunks := make([]models.TextChunk, 0, len(results))
+	for _, f := range results {
+		chunks = append(chunks, f.chunk)
+	}
+
+	return chunks
+}
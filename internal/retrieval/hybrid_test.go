@@ -0,0 +1,195 @@
+package retrieval
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"golf-rules-rag/internal/database"
+	"golf-rules-rag/internal/models"
+)
+
+func ids(chunks []models.TextChunk) []int {
+	result := make([]int, 0, len(chunks))
+	for _, chunk := range chunks {
+		result = append(result, chunk.ID)
+	}
+	return result
+}
+
+func ranking(weight float64, chunkIDs ...int) Ranking {
+	chunks := make([]models.TextChunk, 0, len(chunkIDs))
+	for _, id := range chunkIDs {
+		chunks = append(chunks, models.TextChunk{ID: id})
+	}
+	return Ranking{Chunks: chunks, Weight: weight}
+}
+
+func TestReciprocalRankFusion(t *testing.T) {
+	tests := []struct {
+		name     string
+		k        int
+		limit    int
+		rankings []Ranking
+		want     []int
+	}{
+		{
+			name:     "single ranking keeps its order",
+			k:        60,
+			rankings: []Ranking{ranking(1, 3, 1, 2)},
+			want:     []int{3, 1, 2},
+		},
+		{
+			name:     "chunks in both rankings rise",
+			k:        60,
+			rankings: []Ranking{ranking(1, 1, 2, 3), ranking(1, 4, 3, 5)},
+			want:     []int{3, 1, 4, 2, 5},
+		},
+		{
+			name:     "ties keep the order of first appearance",
+			k:        60,
+			rankings: []Ranking{ranking(1, 1, 2), ranking(1, 2, 1)},
+			want:     []int{1, 2},
+		},
+		{
+			name:     "weights favour a ranking",
+			k:        60,
+			rankings: []Ranking{ranking(1, 1, 2), ranking(3, 2, 1)},
+			want:     []int{2, 1},
+		},
+		{
+			name:     "zero weight ranking does not reorder",
+			k:        60,
+			rankings: []Ranking{ranking(1, 1, 2), ranking(0, 2, 1)},
+			want:     []int{1, 2},
+		},
+		{
+			name:     "limit",
+			k:        60,
+			limit:    2,
+			rankings: []Ranking{ranking(1, 1, 2, 3), ranking(1, 4, 3, 5)},
+			want:     []int{3, 1},
+		},
+		{
+			name:     "large k favours agreement",
+			k:        60,
+			limit:    2,
+			rankings: []Ranking{ranking(1, 1, 6, 7, 8, 9, 2), ranking(1, 3, 10, 11, 12, 13, 2)},
+			want:     []int{2, 1},
+		},
+		{
+			name:     "small k favours top ranks",
+			k:        1,
+			limit:    2,
+			rankings: []Ranking{ranking(1, 1, 6, 7, 8, 9, 2), ranking(1, 3, 10, 11, 12, 13, 2)},
+			want:     []int{1, 3},
+		},
+		{
+			name:     "zero k defaults",
+			rankings: []Ranking{ranking(1, 1, 2, 3), ranking(1, 4, 3, 5)},
+			want:     []int{3, 1, 4, 2, 5},
+		},
+		{
+			name: "no rankings",
+			want: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(ReciprocalRankFusion(tt.k, tt.limit, tt.rankings...))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReciprocalRankFusion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterRule(t *testing.T) {
+	chunks := []models.TextChunk{
+		{ID: 1, Metadata: models.Metadata{Section: "Rule 14"}},
+		{ID: 2, Metadata: models.Metadata{Section: "Rule 14", Subsection: "14.3"}},
+		{ID: 3, Metadata: models.Metadata{Section: "Rule 14", Subsection: "14.3c"}},
+		{ID: 4, Metadata: models.Metadata{Section: "Rule 14", Subsection: "14.3c(1)"}},
+		{ID: 5, Metadata: models.Metadata{Section: "Rule 1", Subsection: "1.2"}},
+		{ID: 6, Metadata: models.Metadata{Section: "Definitions"}},
+	}
+
+	tests := []struct {
+		rule string
+		want []int
+	}{
+		{"Rule 14", []int{1, 2, 3, 4}},
+		{"14", []int{1, 2, 3, 4}},
+		{"Rule 14.3c", []int{3, 4}},
+		{"1", []int{5}},
+		{"Rule 9", []int{}},
+	}
+
+	for _, tt := range tests {
+		if got := ids(FilterRule(chunks, tt.rule)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterRule(%q) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestHybrid(t *testing.T) {
+	ctx := context.Background()
+	store, err := database.NewFileStore(t.TempDir() + "/test.index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RegisterIndex(ctx, models.IndexInfo{Embedding: models.EmbeddingInfo{Dimension: 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chunk IDs are assigned in order from 1
+	for _, chunk := range []models.TextChunk{
+		{Content: "The ball must be dropped in the relief area.", Embedding: []float64{1, 0},
+			Metadata: models.Metadata{Section: "Rule 14", Subsection: "14.3c", Hierarchy: "Rule 14 > 14.3 > 14.3c"}},
+		{Content: "A loose impediment may be removed.", Embedding: []float64{0.9, 0.1},
+			Metadata: models.Metadata{Section: "Rule 15", Subsection: "15.1a", Hierarchy: "Rule 15 > 15.1 > 15.1a"}},
+		{Content: "The flagstick may be left in the hole.", Embedding: []float64{0, 1},
+			Metadata: models.Metadata{Section: "Rule 13", Subsection: "13.2a", Hierarchy: "Rule 13 > 13.2 > 13.2a"}},
+		{Content: "Ball dropped in the wrong way must be dropped again.", Embedding: []float64{0.1, 0.9},
+			Metadata: models.Metadata{Section: "Rule 14", Subsection: "14.3b", Hierarchy: "Rule 14 > 14.3 > 14.3b"}},
+	} {
+		if err := store.StoreTextChunk(ctx, &chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := []float64{1, 0}
+	tests := []struct {
+		name string
+		text string
+		opts HybridOptions
+		want []int
+	}{
+		{"vector only", "anything", HybridOptions{VectorWeight: 1}, []int{1, 2, 4, 3}},
+		{"text only", "dropped ball", HybridOptions{TextWeight: 1}, []int{4, 1}},
+		{"text match lifts a chunk", "flagstick", HybridOptions{VectorWeight: 1, TextWeight: 1}, []int{3, 1, 2, 4}},
+		{"rule filter", "dropped ball", HybridOptions{VectorWeight: 1, TextWeight: 1, Rule: "Rule 14"}, []int{1, 4}},
+		{"subsection filter", "dropped ball", HybridOptions{VectorWeight: 1, TextWeight: 1, Rule: "14.3b"}, []int{4}},
+		{"filter without match", "flagstick", HybridOptions{TextWeight: 1, Rule: "Rule 14"}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := Hybrid(ctx, store, query, tt.text, 4, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(chunks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hybrid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Hybrid(ctx, store, query, "ball", 4, HybridOptions{}); err == nil {
+		t.Error("Hybrid() with no positive weight succeeded")
+	}
+	if _, err := Hybrid(ctx, store, query, "ball", 4, HybridOptions{VectorWeight: -1, TextWeight: 1}); err == nil {
+		t.Error("Hybrid() with a negative weight succeeded")
+	}
+}